@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"unicode"
+	"unicode/utf8"
+)
+
+const alphabetPath = "alphabet.json"
+
+type alphabetEmoji struct {
+	Name string `json:"name"`
+	ID   string `json:"id"`
+}
+
+type alphabetGlyph struct {
+	Codepoint string        `json:"codepoint"`
+	IPA       []string      `json:"ipa"`
+	Aliases   []string      `json:"aliases"`
+	Emoji     alphabetEmoji `json:"emoji"`
+	Template  string        `json:"template"`
+}
+
+type alphabetSequence struct {
+	IPA      string `json:"ipa"`
+	Glyphs   string `json:"glyphs"`
+	Priority int    `json:"priority"`
+}
+
+type alphabetFile struct {
+	Glyphs    []alphabetGlyph    `json:"glyphs"`
+	Sequences []alphabetSequence `json:"sequences"`
+	Nearest   map[string]string  `json:"nearest"`
+}
+
+type alphabet struct {
+	lookup      map[string]string
+	emojiNames  map[string][2]string
+	emojiGlyphs map[string]string
+	templates   map[string]string
+	nearest     map[string]string
+	ipaSymbols  map[rune]bool
+
+	decode      *transliterator
+	normalize   *transliterator
+	encode      *transliterator
+	phoneticKey *transliterator
+	emoji       *transliterator
+	shortcode   *transliterator
+}
+
+var currentAlphabet atomic.Pointer[alphabet]
+
+func activeAlphabet() *alphabet {
+	return currentAlphabet.Load()
+}
+
+func parseCodepoint(codepoint string) (string, error) {
+	value, err := strconv.ParseUint(strings.TrimPrefix(codepoint, "U+"), 16, 32)
+	if err != nil || !strings.HasPrefix(codepoint, "U+") {
+		return "", fmt.Errorf("invalid codepoint %q", codepoint)
+	}
+	return string(rune(value)), nil
+}
+
+func loadAlphabet(path string) (*alphabet, error) {
+	filedata, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file alphabetFile
+	decoder := json.NewDecoder(bytes.NewReader(filedata))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	a := &alphabet{
+		lookup:      map[string]string{},
+		emojiNames:  map[string][2]string{},
+		emojiGlyphs: map[string]string{},
+		templates:   map[string]string{},
+		nearest:     file.Nearest,
+		ipaSymbols:  map[rune]bool{primaryStress: true, secondaryStress: true},
+	}
+	problems := []string{}
+	sounds := map[string]string{}
+	emojiOwners := map[string]string{}
+	encodeRules, normalizeRules := []translitRule{}, []translitRule{}
+
+	for i, entry := range file.Glyphs {
+		glyph, err := parseCodepoint(entry.Codepoint)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("glyph %d: %v", i, err))
+			continue
+		}
+		if _, exists := a.lookup[glyph]; exists {
+			problems = append(problems, fmt.Sprintf("glyph %s is defined twice", glyph))
+			continue
+		}
+		if len(entry.IPA) == 0 {
+			problems = append(problems, fmt.Sprintf("glyph %s has no ipa value", glyph))
+			continue
+		}
+		a.lookup[glyph] = entry.IPA[0]
+
+		for _, sound := range append(slices.Clone(entry.IPA), entry.Aliases...) {
+			if owner, exists := sounds[sound]; exists {
+				problems = append(problems, fmt.Sprintf("ipa %q is mapped by both %s and %s", sound, owner, glyph))
+				continue
+			}
+			sounds[sound] = glyph
+			for _, r := range sound {
+				if r > unicode.MaxASCII {
+					a.ipaSymbols[r] = true
+				}
+			}
+		}
+		for _, sound := range entry.IPA {
+			encodeRules = append(encodeRules, translitRule{from: sound, to: glyph})
+		}
+		for _, alias := range entry.Aliases {
+			normalizeRules = append(normalizeRules, translitRule{from: alias, to: entry.IPA[0]})
+		}
+
+		if (entry.Emoji.Name == "") != (entry.Emoji.ID == "") {
+			problems = append(problems, fmt.Sprintf("glyph %s needs both an emoji name and id", glyph))
+		} else if entry.Emoji.Name != "" {
+			for _, key := range []string{entry.Emoji.Name, entry.Emoji.ID} {
+				if owner, exists := emojiOwners[key]; exists {
+					problems = append(problems, fmt.Sprintf("emoji %s is used by both %s and %s", key, owner, glyph))
+				}
+				emojiOwners[key] = glyph
+			}
+			a.emojiNames[glyph] = [2]string{entry.Emoji.Name, entry.Emoji.ID}
+			a.emojiGlyphs[entry.Emoji.Name] = glyph
+			a.emojiGlyphs[entry.Emoji.ID] = glyph
+		}
+
+		if entry.Template != "" {
+			if _, err := os.Stat(entry.Template); err != nil {
+				problems = append(problems, fmt.Sprintf("glyph %s template: %v", glyph, err))
+			}
+			a.templates[glyph] = entry.Template
+		}
+	}
+
+	sequenceSounds := map[string]bool{}
+	sequenceRules := []translitRule{}
+	for _, sequence := range file.Sequences {
+		if sequenceSounds[sequence.IPA] {
+			problems = append(problems, fmt.Sprintf("sequence %q is defined twice", sequence.IPA))
+		}
+		sequenceSounds[sequence.IPA] = true
+		if owner, exists := sounds[sequence.IPA]; exists && sequence.Priority <= 0 {
+			problems = append(problems, fmt.Sprintf("sequence %q is already mapped by %s, give it a priority to override", sequence.IPA, owner))
+		}
+		for _, r := range sequence.Glyphs {
+			if _, exists := a.lookup[string(r)]; !exists {
+				problems = append(problems, fmt.Sprintf("sequence %q uses undefined glyph %c", sequence.IPA, r))
+			}
+		}
+		sequenceRules = append(sequenceRules, translitRule{from: sequence.IPA, to: sequence.Glyphs, priority: sequence.Priority})
+	}
+
+	emojis, shortcodes := []translitRule{}, []translitRule{}
+	for _, rule := range rulesFromMap(a.lookup) {
+		if emoji, exists := a.emojiNames[rule.from]; exists {
+			emojis = append(emojis, translitRule{from: rule.from, to: fmt.Sprintf("<:%s:%s>", emoji[0], emoji[1])})
+			shortcodes = append(shortcodes, translitRule{from: rule.from, to: fmt.Sprintf(":%s:", emoji[0])})
+		}
+	}
+
+	a.decode = compileTransliterator(rulesFromMap(a.lookup))
+	a.normalize = compileTransliterator(normalizeRules)
+	a.encode = compileTransliterator(sequenceRules, encodeRules)
+	a.phoneticKey = compileTransliterator(phoneticKeyRules, normalizeRules)
+	a.emoji = compileTransliterator(emojis)
+	a.shortcode = compileTransliterator(shortcodes)
+
+	for from, to := range a.nearest {
+		if !a.encodable(a.normalize.translate(to)) {
+			problems = append(problems, fmt.Sprintf("nearest sound %q for %q has no glyph", to, from))
+		}
+	}
+	if failures := a.verifyRoundTrips(slices.Collect(maps.Values(a.lookup))); len(failures) > 0 {
+		problems = append(problems, fmt.Sprintf("sounds do not round-trip: %s", strings.Join(failures, ", ")))
+	}
+
+	if len(problems) > 0 {
+		slices.Sort(problems)
+		return nil, fmt.Errorf("%s: %w", path, errors.New(strings.Join(problems, "; ")))
+	}
+	return a, nil
+}
+
+func initAlphabet() error {
+	a, err := loadAlphabet(alphabetPath)
+	if err != nil {
+		return err
+	}
+	currentAlphabet.Store(a)
+	return nil
+}
+
+func reloadAlphabet() error {
+	a, err := loadAlphabet(alphabetPath)
+	if err != nil {
+		return err
+	}
+	bank, err := loadTemplateBank(a.templates)
+	if err != nil {
+		return err
+	}
+	currentAlphabet.Store(a)
+	storeTemplateBank(bank)
+	if english, exists := languages[defaultLanguage]; exists {
+		storePhoneticIndex(english)
+	}
+	userWords.reindex()
+	return nil
+}
+
+func (a *alphabet) encodable(sounds string) bool {
+	return a.uncovered(sounds) == ""
+}
+
+func (a *alphabet) uncovered(sounds string) string {
+	missing := []string{}
+	for len(sounds) > 0 {
+		if _, size, ok := a.encode.match(sounds); ok {
+			sounds = sounds[size:]
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(sounds)
+		if !slices.Contains(missing, string(r)) {
+			missing = append(missing, string(r))
+		}
+		sounds = sounds[size:]
+	}
+	return strings.Join(missing, " ")
+}
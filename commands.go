@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+func runCommand(args []string) error {
+	if err := initAlphabet(); err != nil {
+		return err
+	}
+	switch args[0] {
+	case "train-g2p":
+		if len(args) != 3 {
+			return fmt.Errorf("usage: train-g2p <dictionary.txt> <model.g2p>")
+		}
+		pronunciations, err := loadDictionary(args[1])
+		if err != nil {
+			return err
+		}
+		return trainG2P(pronunciations).save(args[2])
+	case "roundtrip":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: roundtrip <dictionary.txt>")
+		}
+		pronunciations, err := loadDictionary(args[1])
+		if err != nil {
+			return err
+		}
+		samples := []string{}
+		for _, options := range pronunciations {
+			samples = append(samples, options...)
+		}
+		failures := activeAlphabet().verifyRoundTrips(samples)
+		for _, failure := range failures {
+			fmt.Println(failure)
+		}
+		if len(failures) > 0 {
+			return fmt.Errorf("%d of %d pronunciations do not round-trip", len(failures), len(samples))
+		}
+		fmt.Printf("all %d pronunciations round-trip\n", len(samples))
+		return nil
+	case "lint":
+		if len(args) < 2 {
+			return fmt.Errorf("usage: lint <dictionary.txt>...")
+		}
+		total := 0
+		for _, path := range args[1:] {
+			code := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+			normalization := compileTransliterator(rulesFromMap(languageNormalizations[code]))
+			pronunciations, problems, err := parseDictionary(path, glyphCoverage(normalization))
+			if err != nil {
+				return err
+			}
+			for _, problem := range problems {
+				fmt.Println(problem)
+			}
+			fmt.Printf("%s: %d words, %d problems\n", path, len(pronunciations), len(problems))
+			total += len(problems)
+			compiledPath := strings.TrimSuffix(path, filepath.Ext(path)) + ".dict"
+			if err := verifyCompiledDictionary(compiledPath, path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				fmt.Printf("%v (run go generate)\n", err)
+				total++
+			}
+		}
+		if total > 0 {
+			return fmt.Errorf("%d dictionary problems", total)
+		}
+		return nil
+	case "compile-dict":
+		if len(args) != 3 {
+			return fmt.Errorf("usage: compile-dict <dictionary.txt> <output.dict>")
+		}
+		code := strings.TrimSuffix(filepath.Base(args[1]), filepath.Ext(args[1]))
+		normalization := compileTransliterator(rulesFromMap(languageNormalizations[code]))
+		pronunciations, problems, err := parseDictionary(args[1], glyphCoverage(normalization))
+		if err != nil {
+			return err
+		}
+		for _, problem := range problems {
+			fmt.Println(problem)
+		}
+		if len(problems) > 0 {
+			return fmt.Errorf("refusing to compile %s with %d problems", args[1], len(problems))
+		}
+		return compileDictionary(args[1], pronunciations, args[2])
+	default:
+		return fmt.Errorf("unknown command: %s", args[0])
+	}
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"iter"
+	"maps"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+)
+
+//go:generate go run . compile-dict ipa/en_US.txt ipa/en_US.dict
+
+const compiledDictionaryMagic = "GNDICT03"
+const compiledHeaderSize = len(compiledDictionaryMagic) + 8 + 8 + 4
+
+var errStaleDictionary = errors.New("compiled dictionary does not match its source or the alphabet")
+
+type pronunciationTable interface {
+	lookup(word string) ([]string, bool)
+	words() iter.Seq2[string, []string]
+	size() int
+}
+
+type mapTable map[string][]string
+
+func (t mapTable) lookup(word string) ([]string, bool) {
+	options, exists := t[word]
+	return options, exists
+}
+
+func (t mapTable) words() iter.Seq2[string, []string] {
+	return maps.All(t)
+}
+
+func (t mapTable) size() int {
+	return len(t)
+}
+
+type compiledTable struct {
+	offsets []byte
+	blob    []byte
+	count   int
+}
+
+func sourceHash(paths ...string) (uint64, error) {
+	hash := fnv.New64a()
+	for _, path := range paths {
+		filedata, err := os.ReadFile(path)
+		if err != nil {
+			return 0, err
+		}
+		hash.Write(filedata)
+	}
+	return hash.Sum64(), nil
+}
+
+// sourceStamp fingerprints file sizes and modification times, so startup can spot
+// an edited source without reading it. sourceHash is only checked by lint.
+func sourceStamp(paths ...string) (uint64, error) {
+	hash := fnv.New64a()
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return 0, err
+		}
+		hash.Write(binary.LittleEndian.AppendUint64(nil, uint64(info.Size())))
+		hash.Write(binary.LittleEndian.AppendUint64(nil, uint64(info.ModTime().UnixNano())))
+	}
+	return hash.Sum64(), nil
+}
+
+func compileDictionary(sourcePath string, pronunciations map[string][]string, outputPath string) error {
+	stamp, err := sourceStamp(sourcePath, alphabetPath)
+	if err != nil {
+		return err
+	}
+	hash, err := sourceHash(sourcePath, alphabetPath)
+	if err != nil {
+		return err
+	}
+	words := slices.Sorted(maps.Keys(pronunciations))
+
+	var blob bytes.Buffer
+	offsets := make([]byte, 0, 4*(len(words)+1))
+	for _, word := range words {
+		offsets = binary.LittleEndian.AppendUint32(offsets, uint32(blob.Len()))
+		blob.WriteString(word)
+		for _, option := range pronunciations[word] {
+			blob.WriteByte('\t')
+			blob.WriteString(option)
+		}
+	}
+	offsets = binary.LittleEndian.AppendUint32(offsets, uint32(blob.Len()))
+
+	header := []byte(compiledDictionaryMagic)
+	header = binary.LittleEndian.AppendUint64(header, stamp)
+	header = binary.LittleEndian.AppendUint64(header, hash)
+	header = binary.LittleEndian.AppendUint32(header, uint32(len(words)))
+	return os.WriteFile(outputPath, slices.Concat(header, offsets, blob.Bytes()), 0o644)
+}
+
+func openCompiledDictionary(path string, sourcePath string) (*compiledTable, error) {
+	data, release, err := mapFile(path)
+	if err != nil {
+		return nil, err
+	}
+	table, err := parseCompiledDictionary(data, sourcePath)
+	if err != nil {
+		release()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return table, nil
+}
+
+func parseCompiledDictionary(data []byte, sourcePath string) (*compiledTable, error) {
+	if len(data) < compiledHeaderSize || string(data[:len(compiledDictionaryMagic)]) != compiledDictionaryMagic {
+		return nil, errors.New("not a compiled dictionary")
+	}
+	stamp, err := sourceStamp(sourcePath, alphabetPath)
+	if err != nil {
+		return nil, err
+	}
+	if binary.LittleEndian.Uint64(data[len(compiledDictionaryMagic):]) != stamp {
+		return nil, errStaleDictionary
+	}
+	count := int(binary.LittleEndian.Uint32(data[compiledHeaderSize-4:]))
+	offsetsEnd := compiledHeaderSize + 4*(count+1)
+	if offsetsEnd > len(data) {
+		return nil, errors.New("truncated offset table")
+	}
+	table := &compiledTable{
+		offsets: data[compiledHeaderSize:offsetsEnd],
+		blob:    data[offsetsEnd:],
+		count:   count,
+	}
+	if int(table.offset(count)) != len(table.blob) {
+		return nil, errors.New("truncated entries")
+	}
+	return table, nil
+}
+
+func verifyCompiledDictionary(path string, sourcePath string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if _, err := parseCompiledDictionary(data, sourcePath); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	hash, err := sourceHash(sourcePath, alphabetPath)
+	if err != nil {
+		return err
+	}
+	if binary.LittleEndian.Uint64(data[len(compiledDictionaryMagic)+8:]) != hash {
+		return fmt.Errorf("%s: %w", path, errStaleDictionary)
+	}
+	return nil
+}
+
+func (t *compiledTable) offset(i int) uint32 {
+	return binary.LittleEndian.Uint32(t.offsets[4*i:])
+}
+
+func (t *compiledTable) entry(i int) []byte {
+	return t.blob[t.offset(i):t.offset(i+1)]
+}
+
+func (t *compiledTable) word(i int) []byte {
+	entry := t.entry(i)
+	if tab := bytes.IndexByte(entry, '\t'); tab >= 0 {
+		return entry[:tab]
+	}
+	return entry
+}
+
+func (t *compiledTable) options(i int) []string {
+	fields := strings.Split(string(t.entry(i)), "\t")
+	return fields[1:]
+}
+
+func (t *compiledTable) lookup(word string) ([]string, bool) {
+	key := []byte(word)
+	i := sort.Search(t.count, func(i int) bool {
+		return bytes.Compare(t.word(i), key) >= 0
+	})
+	if i == t.count || !bytes.Equal(t.word(i), key) {
+		return nil, false
+	}
+	return t.options(i), true
+}
+
+func (t *compiledTable) words() iter.Seq2[string, []string] {
+	return func(yield func(string, []string) bool) {
+		for i := range t.count {
+			if !yield(string(t.word(i)), t.options(i)) {
+				return
+			}
+		}
+	}
+}
+
+func (t *compiledTable) size() int {
+	return t.count
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const mebibyte = 1 << 20
+
+func compileTestDictionary(t testing.TB, source string) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	sourcePath := filepath.Join(dir, defaultLanguage+".txt")
+	for _, ext := range []string{".txt", ".g2p"} {
+		filedata, err := os.ReadFile(strings.TrimSuffix(source, ".txt") + ext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(strings.TrimSuffix(sourcePath, ".txt")+ext, filedata, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pronunciations, _, err := parseDictionary(sourcePath, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compiledPath := filepath.Join(dir, defaultLanguage+".dict")
+	if err := compileDictionary(sourcePath, pronunciations, compiledPath); err != nil {
+		t.Fatal(err)
+	}
+	return sourcePath, compiledPath
+}
+
+func TestCompiledDictionaryMatchesSource(t *testing.T) {
+	sourcePath, compiledPath := compileTestDictionary(t, filepath.Join(dictionaryDir, defaultLanguage+".txt"))
+	pronunciations, _, err := parseDictionary(sourcePath, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compiled, err := openCompiledDictionary(compiledPath, sourcePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if compiled.size() != len(pronunciations) {
+		t.Fatalf("compiled %d words from %d", compiled.size(), len(pronunciations))
+	}
+	for word, options := range pronunciations {
+		if got, exists := compiled.lookup(word); !exists || !slices.Equal(got, options) {
+			t.Fatalf("lookup(%q) = %q, want %q", word, got, options)
+		}
+	}
+	if _, exists := compiled.lookup("notaword-xyzzy"); exists {
+		t.Errorf("lookup found a word that isn't in the source")
+	}
+	if words := slices.Collect(maps.Keys(maps.Collect(compiled.words()))); len(words) != len(pronunciations) {
+		t.Errorf("words() yields %d words, want %d", len(words), len(pronunciations))
+	}
+}
+
+func TestCompiledDictionaryIsStaleAfterSourceEdit(t *testing.T) {
+	sourcePath, compiledPath := compileTestDictionary(t, filepath.Join(dictionaryDir, defaultLanguage+".txt"))
+	file, err := os.OpenFile(sourcePath, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString("zorblax\t/ˈzɔɹblæks/\n")
+	file.Close()
+	if _, err := openCompiledDictionary(compiledPath, sourcePath); !errors.Is(err, errStaleDictionary) {
+		t.Errorf("opening after a source edit gave %v, want %v", err, errStaleDictionary)
+	}
+}
+
+func TestCompiledDictionaryIsStaleAfterTouch(t *testing.T) {
+	sourcePath, compiledPath := compileTestDictionary(t, filepath.Join(dictionaryDir, defaultLanguage+".txt"))
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(sourcePath, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := openCompiledDictionary(compiledPath, sourcePath); !errors.Is(err, errStaleDictionary) {
+		t.Errorf("opening after touching the source gave %v, want %v", err, errStaleDictionary)
+	}
+}
+
+func TestVerifyCompiledDictionaryHashesContent(t *testing.T) {
+	sourcePath, compiledPath := compileTestDictionary(t, filepath.Join(dictionaryDir, defaultLanguage+".txt"))
+	if err := verifyCompiledDictionary(compiledPath, sourcePath); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(sourcePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filedata, err := os.ReadFile(sourcePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filedata[len(filedata)-2] ^= 1
+	if err := os.WriteFile(sourcePath, filedata, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(sourcePath, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := openCompiledDictionary(compiledPath, sourcePath); err != nil {
+		t.Errorf("startup rejected a source with the same size and time: %v", err)
+	}
+	if err := verifyCompiledDictionary(compiledPath, sourcePath); !errors.Is(err, errStaleDictionary) {
+		t.Errorf("verifying an edited source gave %v, want %v", err, errStaleDictionary)
+	}
+}
+
+func TestSourceHashCoversEveryFile(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{"dict": "a\t/a/\n", "alphabet": "{}", "edited": "{ }"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	original, err := sourceHash(filepath.Join(dir, "dict"), filepath.Join(dir, "alphabet"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited, err := sourceHash(filepath.Join(dir, "dict"), filepath.Join(dir, "edited"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if original == edited {
+		t.Errorf("editing the alphabet does not change the hash")
+	}
+}
+
+// residentBytes reads the resident set size from /proc, so it only works on Linux.
+func residentBytes() (int64, bool) {
+	filedata, err := os.ReadFile("/proc/self/statm")
+	if err != nil {
+		return 0, false
+	}
+	fields := strings.Fields(string(filedata))
+	if len(fields) < 2 {
+		return 0, false
+	}
+	pages, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return pages * int64(os.Getpagesize()), true
+}
+
+func rssAfter(b *testing.B, load func()) float64 {
+	debug.FreeOSMemory()
+	before, ok := residentBytes()
+	if !ok {
+		b.Skip("resident set size is only measured on Linux")
+	}
+	load()
+	debug.FreeOSMemory()
+	after, _ := residentBytes()
+	return float64(after-before) / mebibyte
+}
+
+func BenchmarkLoadDictionary(b *testing.B) {
+	sourcePath, compiledPath := compileTestDictionary(b, filepath.Join(dictionaryDir, defaultLanguage+".txt"))
+	loaders := map[string]func() (pronunciationTable, error){
+		"compiled": func() (pronunciationTable, error) {
+			return openCompiledDictionary(compiledPath, sourcePath)
+		},
+		"tsv": func() (pronunciationTable, error) {
+			pronunciations, _, err := parseDictionary(sourcePath, nil)
+			return mapTable(pronunciations), err
+		},
+	}
+	for _, name := range []string{"compiled", "tsv"} {
+		b.Run(name, func(b *testing.B) {
+			var table pronunciationTable
+			rss := 0.0
+			for range b.N {
+				table = nil
+				rss = rssAfter(b, func() {
+					var err error
+					if table, err = loaders[name](); err != nil {
+						b.Fatal(err)
+					}
+				})
+			}
+			b.ReportMetric(rss, "rss-MiB")
+			runtime.KeepAlive(table)
+		})
+	}
+}
+
+func BenchmarkLookup(b *testing.B) {
+	sourcePath, compiledPath := compileTestDictionary(b, filepath.Join(dictionaryDir, defaultLanguage+".txt"))
+	pronunciations, _, err := parseDictionary(sourcePath, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	compiled, err := openCompiledDictionary(compiledPath, sourcePath)
+	if err != nil {
+		b.Fatal(err)
+	}
+	sample := []string{}
+	for _, word := range slices.Sorted(maps.Keys(pronunciations)) {
+		if len(sample) < 4096 && len(word)%3 == 0 {
+			sample = append(sample, word)
+		}
+	}
+	for name, table := range map[string]pronunciationTable{"compiled": compiled, "tsv": mapTable(pronunciations)} {
+		b.Run(name, func(b *testing.B) {
+			for i := range b.N {
+				table.lookup(sample[i%len(sample)])
+			}
+		})
+	}
+}
+
+func BenchmarkStartup(b *testing.B) {
+	if err := initAlphabet(); err != nil {
+		b.Fatal(err)
+	}
+	sourcePath, _ := compileTestDictionary(b, filepath.Join(dictionaryDir, defaultLanguage+".txt"))
+	for _, phonetics := range []string{"lazy", "built"} {
+		b.Run(phonetics, func(b *testing.B) {
+			rss := 0.0
+			for range b.N {
+				currentPhonetics.Store(nil)
+				rss = rssAfter(b, func() {
+					language, _, err := loadLanguage(defaultLanguage, sourcePath)
+					if err != nil {
+						b.Fatal(err)
+					}
+					storePhoneticIndex(language)
+					if phonetics == "built" {
+						currentPhonetics.Load().load()
+					}
+				})
+			}
+			b.ReportMetric(rss, "rss-MiB")
+		})
+	}
+}
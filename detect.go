@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	decodeText  = "text"
+	decodeImage = "image"
+	decodeAuto  = "auto"
+)
+
+const (
+	detectedImage  = "image"
+	detectedEmoji  = "emoji"
+	detectedGlyphs = "glyphs"
+	detectedIPA    = "ipa"
+)
+
+var imageSignatures = [][]byte{
+	[]byte("\x89PNG\r\n\x1a\n"),
+	{0xff, 0xd8, 0xff},
+	[]byte("GIF87a"),
+	[]byte("GIF89a"),
+	[]byte("BM"),
+	[]byte("II*\x00"),
+	[]byte("MM\x00*"),
+}
+
+func isImageData(data []byte) bool {
+	for _, signature := range imageSignatures {
+		if bytes.HasPrefix(data, signature) {
+			return true
+		}
+	}
+	return len(data) > 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WEBP"
+}
+
+func decodeImageData(encoded string) ([]byte, error) {
+	encoded = strings.TrimSpace(encoded)
+	if strings.HasPrefix(encoded, "data:") {
+		if comma := strings.Index(encoded, ","); comma >= 0 {
+			encoded = encoded[comma+1:]
+		}
+	}
+	return base64.StdEncoding.DecodeString(encoded)
+}
+
+func detectAlienFormat(text string) string {
+	if emojiPattern.MatchString(text) && activeAlphabet().parseEmojis(text) != text {
+		return detectedEmoji
+	}
+	return detectedGlyphs
+}
+
+func detectTextFormat(text string) string {
+	a := activeAlphabet()
+	if format := detectAlienFormat(text); format == detectedEmoji {
+		return format
+	}
+	for _, r := range text {
+		if _, exists := a.lookup[string(r)]; exists {
+			return detectedGlyphs
+		}
+	}
+	if strings.ContainsFunc(text, func(r rune) bool { return a.ipaSymbols[r] }) {
+		return detectedIPA
+	}
+	return ""
+}
+
+func detectDecodeFormat(request DecodeRequest) (string, error) {
+	candidate := request.Image
+	if candidate == "" {
+		candidate = request.Text
+	}
+	if data, err := decodeImageData(candidate); err == nil && isImageData(data) {
+		return detectedImage, nil
+	}
+	if request.Image != "" {
+		return "", fmt.Errorf("image is not a recognised png, jpeg, gif, bmp, tiff or webp file")
+	}
+	format := detectTextFormat(request.Text)
+	if format == "" {
+		return "", fmt.Errorf("could not detect the format of the text")
+	}
+	if format == detectedIPA {
+		a := activeAlphabet()
+		sounds := a.normalize.translate(norm.NFC.String(request.Text))
+		if !a.encodable(strings.Join(strings.FieldsFunc(sounds, func(r rune) bool {
+			return unicode.IsSpace(r) || unicode.IsPunct(r)
+		}), "")) {
+			return "", fmt.Errorf("could not detect the format of the text")
+		}
+	}
+	return format, nil
+}
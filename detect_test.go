@@ -0,0 +1,51 @@
+package main
+
+import "testing"
+
+func TestDetectDecodeFormat(t *testing.T) {
+	if err := initAlphabet(); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		text string
+		want string
+	}{
+		{text: "☝☞", want: detectedGlyphs},
+		{text: "<:hvz_13:1354569354355806389>", want: detectedEmoji},
+		{text: ":hvz_13: :hvz_10:", want: detectedEmoji},
+		{text: "ˈhɛloʊ", want: detectedIPA},
+		{text: "hɛloʊ wɝld", want: detectedIPA},
+		{text: "hello", want: ""},
+		{text: "hello world", want: ""},
+		{text: "café", want: ""},
+		{text: "", want: ""},
+	}
+	for _, test := range tests {
+		got, err := detectDecodeFormat(DecodeRequest{Type: decodeAuto, Text: test.text})
+		if test.want == "" {
+			if err == nil {
+				t.Errorf("detectDecodeFormat(%q) = %q, want an error", test.text, got)
+			}
+			continue
+		}
+		if err != nil || got != test.want {
+			t.Errorf("detectDecodeFormat(%q) = %q, %v, want %q", test.text, got, err, test.want)
+		}
+	}
+}
+
+func TestDetectAlienFormat(t *testing.T) {
+	if err := initAlphabet(); err != nil {
+		t.Fatal(err)
+	}
+	for text, want := range map[string]string{
+		"hello":                         detectedGlyphs,
+		"ˈhɛloʊ":                        detectedGlyphs,
+		"☝☞":                            detectedGlyphs,
+		"<:hvz_13:1354569354355806389>": detectedEmoji,
+	} {
+		if got := detectAlienFormat(text); got != want {
+			t.Errorf("detectAlienFormat(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
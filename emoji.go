@@ -0,0 +1,24 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+var emojiPattern = regexp.MustCompile(`<a?:(\w*):(\d*)>|:(\w+):`)
+
+func (a *alphabet) parseEmojis(text string) string {
+	return emojiPattern.ReplaceAllStringFunc(text, func(markup string) string {
+		groups := emojiPattern.FindStringSubmatch(markup)
+		for _, key := range []string{groups[1], groups[3], strings.ToLower(groups[1]), strings.ToLower(groups[3]), groups[2]} {
+			if glyph, exists := a.emojiGlyphs[key]; key != "" && exists {
+				return glyph
+			}
+		}
+		return markup
+	})
+}
+
+func parseAlienText(text string) string {
+	return activeAlphabet().parseEmojis(text)
+}
@@ -58,6 +58,12 @@ function OutputDecode(props: { payload: DecodeResponse }) {
                 {props.payload.phonetics}
             </textarea>
         </div>
+        <div>
+            <span>Likely words:</span>
+            <textarea readOnly={true} class={"w-full h-16 box-border resize-y"}>
+                {props.payload.letters}
+            </textarea>
+        </div>
     </div>
 }
 
@@ -6,30 +6,115 @@ export enum AlienFormat {
 type DecodeRequestText = {
     type: AlienFormat.TEXT;
     text: string;
+    alternatives?: number;
 }
 
+export type Recognizer = "template" | "components"
+
 type DecodeRequestImage = {
     type: AlienFormat.IMAGE;
     image: string;
+    alternatives?: number;
+    recognizer?: Recognizer;
+    overlap?: number;
+}
+
+type DecodeRequestAuto = {
+    type: "auto";
+    text?: string;
+    image?: string;
+    alternatives?: number;
+    recognizer?: Recognizer;
+    overlap?: number;
 }
 
-export type DecodeRequest = DecodeRequestText | DecodeRequestImage
+export type DecodeRequest = DecodeRequestText | DecodeRequestImage | DecodeRequestAuto
+
+export type DetectedFormat = "image" | "emoji" | "glyphs" | "ipa"
+
+export type WordCandidate = {
+    word: string;
+    score: number;
+}
+
+export type DecodedWord = {
+    phonetics: string;
+    candidates: WordCandidate[];
+}
+
+export type AlignedToken = {
+    source: string;
+    ipa: string;
+    glyphs: string;
+    found: boolean;
+    offset: number;
+}
 
 export type DecodeResponse = {
     phonetics: string;
     alien: string;
+    letters: string;
+    words: DecodedWord[];
+    tokens: AlignedToken[];
+    detected: DetectedFormat;
+}
+
+export enum UnmappedPolicy {
+    KEEP = "keep",
+    DROP = "drop",
+    NEAREST = "nearest"
+}
+
+export enum StressMode {
+    FULL = "full",
+    PRIMARY = "primary",
+    NONE = "none",
+    WORD_INITIAL = "word-initial"
+}
+
+export enum OutputFormat {
+    GLYPHS = "glyphs",
+    EMOJI = "emoji",
+    SHORTCODE = "shortcode",
+    IPA = "ipa"
 }
 
 export type EncodeRequest = {
     type: AlienFormat;
     text: string;
+    lang?: string;
+    unmapped?: UnmappedPolicy;
+    stress?: StressMode;
+    output?: OutputFormat;
+    pronunciations?: Record<number, number>;
 }
 
 const BASE_URL = "/api/v1"
 
+export type UnmappedSegment = {
+    segment: string;
+    word: string;
+    wordOffset: number;
+    offset: number;
+}
+
+export type WordVariants = {
+    word: string;
+    index: number;
+    offset: number;
+    options: string[];
+    chosen: number;
+}
+
 export type EncodeResponse = {
     text: string;
     image: string;
+    guessed: string[];
+    unmapped: UnmappedSegment[];
+    variants: WordVariants[];
+    tokens: AlignedToken[];
+    output: OutputFormat;
+    tooLongForDiscord: boolean;
 }
 
 export async function decode(req: DecodeRequest) {
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+//go:generate go run . train-g2p ipa/en_US.txt ipa/en_US.g2p
+
+const g2pBoundary = '#'
+const g2pTrainingRounds = 4
+
+type g2pModel struct {
+	contexts map[string]string
+}
+
+type g2pAlignment struct {
+	letters []rune
+	chunks  []string
+}
+
+func g2pTrainable(word string) bool {
+	if strings.IndexFunc(word, unicode.IsLetter) < 0 {
+		return false
+	}
+	for _, r := range word {
+		if !unicode.IsLetter(r) && r != '\'' {
+			return false
+		}
+	}
+	return true
+}
+
+func stripStress(ipa string) string {
+	return strings.NewReplacer("ˈ", "", "ˌ", "").Replace(ipa)
+}
+
+func alignWord(letters []rune, phonemes []rune, scores map[rune]map[string]float64) ([]string, bool) {
+	if len(phonemes) > 2*len(letters) {
+		return nil, false
+	}
+	score := func(letter rune, chunk string) float64 {
+		if s, exists := scores[letter][chunk]; exists {
+			return s
+		}
+		return -20 - float64(len([]rune(chunk)))
+	}
+
+	best := make([][]float64, len(letters)+1)
+	step := make([][]int, len(letters)+1)
+	for i := range best {
+		best[i] = make([]float64, len(phonemes)+1)
+		step[i] = make([]int, len(phonemes)+1)
+		for j := range best[i] {
+			best[i][j] = math.Inf(-1)
+		}
+	}
+	best[0][0] = 0
+
+	for i := 1; i <= len(letters); i++ {
+		for j := 0; j <= len(phonemes); j++ {
+			for width := 0; width <= 2 && width <= j; width++ {
+				if math.IsInf(best[i-1][j-width], -1) {
+					continue
+				}
+				candidate := best[i-1][j-width] + score(letters[i-1], string(phonemes[j-width:j]))
+				if candidate > best[i][j] {
+					best[i][j] = candidate
+					step[i][j] = width
+				}
+			}
+		}
+	}
+	if math.IsInf(best[len(letters)][len(phonemes)], -1) {
+		return nil, false
+	}
+
+	chunks := make([]string, len(letters))
+	j := len(phonemes)
+	for i := len(letters); i > 0; i-- {
+		width := step[i][j]
+		chunks[i-1] = string(phonemes[j-width : j])
+		j -= width
+	}
+	return chunks, true
+}
+
+func initialAlignmentScores(words []string, pronunciations map[string][]string) map[rune]map[string]float64 {
+	counts := map[rune]map[string]float64{}
+	for _, word := range words {
+		letters := []rune(word)
+		phonemes := []rune(stripStress(pronunciations[word][0]))
+		if len(phonemes) == 0 {
+			continue
+		}
+		for i, letter := range letters {
+			if counts[letter] == nil {
+				counts[letter] = map[string]float64{"": 0.5}
+			}
+			diagonal := i * len(phonemes) / len(letters)
+			for j := max(0, diagonal-1); j <= min(len(phonemes)-1, diagonal+1); j++ {
+				counts[letter][string(phonemes[j])]++
+			}
+			if diagonal+1 < len(phonemes) {
+				counts[letter][string(phonemes[diagonal:diagonal+2])] += 0.5
+			}
+		}
+	}
+
+	scores := map[rune]map[string]float64{}
+	for letter, chunkCounts := range counts {
+		total := 0.0
+		for _, count := range chunkCounts {
+			total += count
+		}
+		scores[letter] = map[string]float64{}
+		for chunk, count := range chunkCounts {
+			scores[letter][chunk] = math.Log(count / total)
+		}
+	}
+	return scores
+}
+
+func g2pContexts(letters []rune, i int) []string {
+	at := func(k int) rune {
+		if k < 0 || k >= len(letters) {
+			return g2pBoundary
+		}
+		return letters[k]
+	}
+	return []string{
+		string([]rune{at(i - 2), at(i - 1), at(i), at(i + 1), at(i + 2)}),
+		string([]rune{at(i - 1), at(i), at(i + 1)}),
+		string([]rune{at(i)}),
+	}
+}
+
+func trainG2P(pronunciations map[string][]string) *g2pModel {
+	words := []string{}
+	for word := range pronunciations {
+		if g2pTrainable(word) {
+			words = append(words, word)
+		}
+	}
+	slices.Sort(words)
+
+	scores := initialAlignmentScores(words, pronunciations)
+	alignments := []g2pAlignment{}
+	for range g2pTrainingRounds {
+		counts := map[rune]map[string]int{}
+		alignments = alignments[:0]
+		for _, word := range words {
+			letters := []rune(word)
+			chunks, ok := alignWord(letters, []rune(stripStress(pronunciations[word][0])), scores)
+			if !ok {
+				continue
+			}
+			alignments = append(alignments, g2pAlignment{letters: letters, chunks: chunks})
+			for i, letter := range letters {
+				if counts[letter] == nil {
+					counts[letter] = map[string]int{}
+				}
+				counts[letter][chunks[i]]++
+			}
+		}
+
+		scores = map[rune]map[string]float64{}
+		for letter, chunkCounts := range counts {
+			total := 0
+			for _, count := range chunkCounts {
+				total += count
+			}
+			scores[letter] = map[string]float64{}
+			for chunk, count := range chunkCounts {
+				scores[letter][chunk] = math.Log(float64(count) / float64(total))
+			}
+		}
+	}
+
+	contextCounts := map[string]map[string]int{}
+	for _, alignment := range alignments {
+		for i := range alignment.letters {
+			for _, context := range g2pContexts(alignment.letters, i) {
+				if contextCounts[context] == nil {
+					contextCounts[context] = map[string]int{}
+				}
+				contextCounts[context][alignment.chunks[i]]++
+			}
+		}
+	}
+
+	bestChunk := func(context string) (string, int) {
+		chunk, count := "", -1
+		for candidate, n := range contextCounts[context] {
+			if n > count || (n == count && candidate < chunk) {
+				chunk, count = candidate, n
+			}
+		}
+		return chunk, count
+	}
+
+	model := &g2pModel{contexts: map[string]string{}}
+	for context := range contextCounts {
+		chunk, count := bestChunk(context)
+		runes := []rune(context)
+		switch len(runes) {
+		case 1:
+			model.contexts[context] = chunk
+		case 3:
+			if fallback, _ := bestChunk(string(runes[1])); chunk != fallback {
+				model.contexts[context] = chunk
+			}
+		case 5:
+			if fallback, _ := bestChunk(string(runes[1:4])); count > 1 && chunk != fallback {
+				model.contexts[context] = chunk
+			}
+		}
+	}
+	return model
+}
+
+func (model *g2pModel) guess(word string) (string, bool) {
+	if model == nil || !g2pTrainable(word) {
+		return "", false
+	}
+	letters := []rune(word)
+	var ipa strings.Builder
+	ipa.WriteString("ˈ")
+	for i := range letters {
+		for _, context := range g2pContexts(letters, i) {
+			if chunk, exists := model.contexts[context]; exists {
+				ipa.WriteString(chunk)
+				break
+			}
+		}
+	}
+	if ipa.Len() == len("ˈ") {
+		return "", false
+	}
+	return ipa.String(), true
+}
+
+func (model *g2pModel) save(path string) error {
+	contexts := make([]string, 0, len(model.contexts))
+	for context := range model.contexts {
+		contexts = append(contexts, context)
+	}
+	slices.Sort(contexts)
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	writer := bufio.NewWriter(file)
+	for _, context := range contexts {
+		fmt.Fprintf(writer, "%s\t%s\n", context, model.contexts[context])
+	}
+	return writer.Flush()
+}
+
+func loadG2P(path string) (*g2pModel, error) {
+	filedata, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	model := &g2pModel{contexts: map[string]string{}}
+	for i, line := range strings.Split(string(filedata), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if line == "" {
+			continue
+		}
+		chunks := strings.Split(line, "\t")
+		if len(chunks) != 2 {
+			return nil, fmt.Errorf("%s:%d: expected context<TAB>phonemes, found %d fields", path, i+1, len(chunks))
+		}
+		context, phonemes := chunks[0], chunks[1]
+		if length := utf8.RuneCountInString(context); length != 1 && length != 3 && length != 5 {
+			return nil, fmt.Errorf("%s:%d: context %q is %d letters, want 1, 3 or 5", path, i+1, context, length)
+		}
+		if utf8.RuneCountInString(phonemes) > 2 {
+			return nil, fmt.Errorf("%s:%d: context %q maps to %q, more than two phonemes", path, i+1, context, phonemes)
+		}
+		if _, exists := model.contexts[context]; exists {
+			return nil, fmt.Errorf("%s:%d: context %q is defined twice", path, i+1, context)
+		}
+		model.contexts[context] = phonemes
+	}
+	if len(model.contexts) == 0 {
+		return nil, fmt.Errorf("%s: model has no contexts", path)
+	}
+	return model, nil
+}
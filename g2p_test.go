@@ -0,0 +1,131 @@
+package main
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+var heldOutWords = []string{"stamp", "drift", "gnarl", "knack", "thrift", "sting", "whiff", "clamp"}
+
+type heldOutSplit struct {
+	model   *g2pModel
+	heldOut map[string][]string
+}
+
+var trainHeldOutModel = sync.OnceValues(func() (heldOutSplit, error) {
+	if err := initAlphabet(); err != nil {
+		return heldOutSplit{}, err
+	}
+	pronunciations, _, err := parseDictionary(filepath.Join(dictionaryDir, defaultLanguage+".txt"), nil)
+	if err != nil {
+		return heldOutSplit{}, err
+	}
+	training, heldOut := map[string][]string{}, map[string][]string{}
+	for i, word := range slices.Sorted(maps.Keys(pronunciations)) {
+		switch {
+		case slices.Contains(heldOutWords, word) || (i%250 == 1 && g2pTrainable(word)):
+			heldOut[word] = pronunciations[word]
+		case i%5 == 0:
+			training[word] = pronunciations[word]
+		}
+	}
+	return heldOutSplit{model: trainG2P(training), heldOut: heldOut}, nil
+})
+
+func heldOutModel(t *testing.T) (*g2pModel, map[string][]string) {
+	t.Helper()
+	split, err := trainHeldOutModel()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return split.model, split.heldOut
+}
+
+func TestGuessHeldOutWords(t *testing.T) {
+	model, heldOut := heldOutModel(t)
+	for _, word := range heldOutWords {
+		if guess, ok := model.guess(word); !ok || !slices.Contains(heldOut[word], guess) {
+			t.Errorf("guessed %s as %q, want one of %q", word, guess, heldOut[word])
+		}
+	}
+
+	near := 0
+	for word, options := range heldOut {
+		guess, ok := model.guess(word)
+		if !ok {
+			continue
+		}
+		key := []rune(phoneticKey(guess))
+		if slices.ContainsFunc(options, func(option string) bool {
+			return phoneticDistance(key, []rune(phoneticKey(option))) <= float64(len(key))/3
+		}) {
+			near++
+		}
+	}
+	if near < len(heldOut)*9/10 {
+		t.Errorf("only %d of %d held-out words are guessed within a third of their length", near, len(heldOut))
+	}
+}
+
+func TestGuessRejectsUntrainableWords(t *testing.T) {
+	model, _ := heldOutModel(t)
+	for _, word := range []string{"", "42", "c3po", "rock-n-roll"} {
+		if guess, ok := model.guess(word); ok {
+			t.Errorf("guessed %q as %q", word, guess)
+		}
+	}
+	var missing *g2pModel
+	if _, ok := missing.guess("stamp"); ok {
+		t.Errorf("a nil model guessed a word")
+	}
+}
+
+func TestSavedModelLoads(t *testing.T) {
+	model, _ := heldOutModel(t)
+	path := filepath.Join(t.TempDir(), "model.g2p")
+	if err := model.save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := loadG2P(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !maps.Equal(loaded.contexts, model.contexts) {
+		t.Errorf("loaded %d contexts, saved %d", len(loaded.contexts), len(model.contexts))
+	}
+	if _, err := loadG2P(filepath.Join(dictionaryDir, defaultLanguage+".g2p")); err != nil {
+		t.Errorf("bundled model does not load: %v", err)
+	}
+}
+
+func TestLoadMalformedModel(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{name: "missing phonemes", content: "a\tæ\nb\n", err: ":2: expected context<TAB>phonemes"},
+		{name: "extra field", content: "a\tæ\tə\n", err: ":1: expected context<TAB>phonemes"},
+		{name: "context length", content: "ab\tæ\n", err: ":1: context \"ab\" is 2 letters"},
+		{name: "long chunk", content: "#a#\tæks\n", err: ":1: context \"#a#\" maps to \"æks\""},
+		{name: "duplicate context", content: "a\tæ\nb\tb\na\tə\n", err: ":3: context \"a\" is defined twice"},
+		{name: "empty", content: "\n", err: "model has no contexts"},
+	}
+	dir := t.TempDir()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(dir, strings.ReplaceAll(test.name, " ", "_")+".g2p")
+			if err := os.WriteFile(path, []byte(test.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := loadG2P(path); err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("got error %v, want %q", err, test.err)
+			}
+		})
+	}
+}
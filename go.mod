@@ -8,6 +8,7 @@ require (
 	github.com/sirupsen/logrus v1.9.3
 	gocv.io/x/gocv v0.41.0
 	golang.org/x/image v0.25.0
+	golang.org/x/text v0.23.0
 )
 
 require (
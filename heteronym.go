@@ -0,0 +1,109 @@
+package main
+
+import (
+	"slices"
+	"strings"
+)
+
+const (
+	contextNoun    = "noun"
+	contextVerb    = "verb"
+	contextPerfect = "perfect"
+)
+
+type WordVariants struct {
+	Word    string   `json:"word"`
+	Index   int      `json:"index"`
+	Offset  int      `json:"offset"`
+	Options []string `json:"options"`
+	Chosen  int      `json:"chosen"`
+}
+
+var languageContexts = map[string]map[string]string{
+	"en_US": {
+		"a": contextNoun, "an": contextNoun, "the": contextNoun, "my": contextNoun, "your": contextNoun,
+		"his": contextNoun, "her": contextNoun, "its": contextNoun, "our": contextNoun, "their": contextNoun,
+		"this": contextNoun, "these": contextNoun, "those": contextNoun, "every": contextNoun, "each": contextNoun,
+		"some": contextNoun, "no": contextNoun,
+
+		"to": contextVerb, "will": contextVerb, "would": contextVerb, "can": contextVerb, "could": contextVerb,
+		"shall": contextVerb, "should": contextVerb, "may": contextVerb, "might": contextVerb, "must": contextVerb,
+		"do": contextVerb, "did": contextVerb, "don't": contextVerb, "didn't": contextVerb, "won't": contextVerb,
+		"can't": contextVerb, "i": contextVerb, "you": contextVerb, "we": contextVerb, "they": contextVerb,
+		"please": contextVerb, "let's": contextVerb, "not": contextVerb,
+
+		"have": contextPerfect, "has": contextPerfect, "had": contextPerfect, "having": contextPerfect,
+		"i've": contextPerfect, "you've": contextPerfect, "we've": contextPerfect, "they've": contextPerfect,
+		"was": contextPerfect, "were": contextPerfect, "been": contextPerfect, "being": contextPerfect,
+		"is": contextPerfect, "are": contextPerfect, "be": contextPerfect,
+	},
+}
+
+var languageHeteronyms = map[string]map[string]map[string]string{
+	"en_US": {
+		"close":  {"": "ˈkɫoʊs", contextVerb: "ˈkɫoʊz"},
+		"does":   {"": "ˈdəz"},
+		"lead":   {"": "ˈɫid"},
+		"live":   {"": "ˈɫɪv", contextNoun: "ˈɫaɪv", contextPerfect: "ˈɫaɪv"},
+		"minute": {"": "ˈmɪnət"},
+		"read":   {"": "ˈɹid", contextPerfect: "ˈɹɛd"},
+		"tear":   {"": "ˈtɪɹ", contextVerb: "ˈtɛɹ"},
+		"use":    {"": "ˈjuz", contextNoun: "ˈjus"},
+		"wind":   {"": "ˈwɪnd", contextVerb: "ˈwaɪnd"},
+		"wound":  {"": "ˈwund", contextPerfect: "ˈwaʊnd"},
+	},
+}
+
+func distinctSounds(variants []string) int {
+	sounds := map[string]bool{}
+	for _, variant := range variants {
+		sounds[stripStress(variant)] = true
+	}
+	return len(sounds)
+}
+
+func neighbourWord(words []string, i int, step int) string {
+	for j := i + step; j >= 0 && j < len(words); j += step {
+		if strings.TrimSpace(words[j]) == "" {
+			continue
+		}
+		if strings.IndexFunc(words[j], isWordRune) < 0 {
+			return ""
+		}
+		return words[j]
+	}
+	return ""
+}
+
+func (l *dictionaryLanguage) Choose(word string, previous string, next string) int {
+	variants := l.Variants(word)
+	if len(variants) < 2 {
+		return 0
+	}
+	context := l.contexts[previous]
+	if context == "" && l.contexts[next] == contextNoun {
+		context = contextVerb
+	}
+
+	if rules, exists := l.heteronyms[word]; exists {
+		for _, key := range []string{context, ""} {
+			if i := slices.Index(variants, rules[key]); i >= 0 {
+				return i
+			}
+		}
+		return 0
+	}
+
+	if context == contextNoun || context == contextVerb {
+		for i, variant := range variants {
+			stress := strings.Index(variant, "ˈ")
+			if stress < 0 {
+				continue
+			}
+			if (stress == 0) == (context == contextNoun) {
+				return i
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestChooseHeteronym(t *testing.T) {
+	language := testLanguage(t)
+	tests := []struct {
+		name     string
+		previous string
+		word     string
+		next     string
+		want     string
+	}{
+		{name: "read defaults to the present", word: "read", want: "ˈɹid"},
+		{name: "read after have", previous: "have", word: "read", want: "ˈɹɛd"},
+		{name: "read after to", previous: "to", word: "read", want: "ˈɹid"},
+		{name: "live after a", previous: "a", word: "live", want: "ˈɫaɪv"},
+		{name: "live after we", previous: "we", word: "live", want: "ˈɫɪv"},
+		{name: "close after will", previous: "will", word: "close", want: "ˈkɫoʊz"},
+		{name: "close before a noun is a verb", word: "close", next: "the", want: "ˈkɫoʊz"},
+		{name: "use after the", previous: "the", word: "use", want: "ˈjus"},
+		{name: "wound after was", previous: "was", word: "wound", want: "ˈwaʊnd"},
+		{name: "record after a", previous: "a", word: "record", want: "ˈɹɛkɝd"},
+		{name: "record after to", previous: "to", word: "record", want: "ɹəˈkɔɹd"},
+		{name: "object after the", previous: "the", word: "object", want: "ˈɑbdʒɛkt"},
+		{name: "object after i", previous: "i", word: "object", want: "əbˈdʒɛkt"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			variants := language.Variants(test.word)
+			if !slices.Contains(variants, test.want) {
+				t.Fatalf("%s has no variant %q in %q", test.word, test.want, variants)
+			}
+			if got := variants[language.Choose(test.word, test.previous, test.next)]; got != test.want {
+				t.Errorf("chose %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestNeighbourWord(t *testing.T) {
+	words := []string{"I", " ", "have", " ", "read", ",", " ", "it"}
+	if got := neighbourWord(words, 4, -1); got != "have" {
+		t.Errorf("word before read is %q", got)
+	}
+	if got := neighbourWord(words, 4, 1); got != "" {
+		t.Errorf("punctuation after read gives %q", got)
+	}
+	if got := neighbourWord(words, 0, -1); got != "" {
+		t.Errorf("first word has neighbour %q", got)
+	}
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const defaultLanguage = "en_US"
+const dictionaryDir = "ipa"
+
+type Language interface {
+	Code() string
+	Verbalize(text string) []verbalSegment
+	Tokenize(text string) [][]int
+	Dictionary() pronunciationTable
+	Frequencies() map[string]int
+	Lookup(word string) (string, bool)
+	Variants(word string) []string
+	Choose(word string, previous string, next string) int
+	Normalize(sounds string) string
+	Guess(word string) (string, bool)
+}
+
+type dictionaryLanguage struct {
+	code           string
+	pronunciations pronunciationTable
+	frequencies    map[string]int
+	normalization  *transliterator
+	elisions       map[string]string
+	contexts       map[string]string
+	heteronyms     map[string]map[string]string
+	verbalizer     *verbalizer
+	g2p            *g2pModel
+}
+
+var languages = map[string]Language{}
+
+func (l *dictionaryLanguage) Code() string {
+	return l.code
+}
+
+func (l *dictionaryLanguage) Verbalize(text string) []verbalSegment {
+	return l.verbalizer.verbalize(text)
+}
+
+func (l *dictionaryLanguage) Tokenize(text string) [][]int {
+	return tokenizeWords(text, func(word string) bool {
+		_, exists := l.Lookup(word)
+		return exists
+	}, l.elisions)
+}
+
+func (l *dictionaryLanguage) Dictionary() pronunciationTable {
+	return l.pronunciations
+}
+
+func (l *dictionaryLanguage) Frequencies() map[string]int {
+	return l.frequencies
+}
+
+func (l *dictionaryLanguage) Lookup(word string) (string, bool) {
+	variants := l.Variants(word)
+	if len(variants) == 0 {
+		return "", false
+	}
+	return variants[0], true
+}
+
+func (l *dictionaryLanguage) Variants(word string) []string {
+	if ipa, exists := userWords.lookup(l.code, word); exists {
+		return []string{ipa}
+	}
+	if options, exists := l.pronunciations.lookup(word); exists {
+		return options
+	}
+	if elided, exists := l.elisions[word]; exists {
+		return []string{elided}
+	}
+	options, _ := l.pronunciations.lookup(stripAccents(word))
+	return options
+}
+
+func (l *dictionaryLanguage) Normalize(sounds string) string {
+	return l.normalization.translate(sounds)
+}
+
+func (l *dictionaryLanguage) Guess(word string) (string, bool) {
+	return l.g2p.guess(word)
+}
+
+type LanguageStatus struct {
+	Code     string   `json:"code"`
+	Words    int      `json:"words"`
+	Degraded bool     `json:"degraded"`
+	Problems []string `json:"problems"`
+}
+
+const maxReportedProblems = 20
+
+var languageReports = map[string]LanguageStatus{}
+
+func glyphCoverage(normalization *transliterator) func(ipa string) string {
+	a := activeAlphabet()
+	return func(ipa string) string {
+		return a.uncovered(a.normalize.translate(normalization.translate(ipa)))
+	}
+}
+
+func loadLanguage(code string, dictionaryPath string) (*dictionaryLanguage, []dictionaryProblem, error) {
+	normalization := compileTransliterator(rulesFromMap(languageNormalizations[code]))
+	basePath := strings.TrimSuffix(dictionaryPath, filepath.Ext(dictionaryPath))
+	var pronunciations pronunciationTable
+	problems := []dictionaryProblem{}
+	if compiled, err := openCompiledDictionary(basePath+".dict", dictionaryPath); err == nil {
+		pronunciations = compiled
+	} else {
+		if !errors.Is(err, fs.ErrNotExist) {
+			log.Warnf("could not use compiled %s dictionary, parsing %s instead: %v", code, dictionaryPath, err)
+		}
+		parsed, parseProblems, err := parseDictionary(dictionaryPath, glyphCoverage(normalization))
+		if err != nil {
+			return nil, nil, err
+		}
+		pronunciations, problems = mapTable(parsed), parseProblems
+	}
+	model, err := loadG2P(basePath + ".g2p")
+	if err != nil {
+		log.Warnf("could not load %s g2p model, training from dictionary: %v", code, err)
+		model = trainG2P(maps.Collect(pronunciations.words()))
+	}
+	frequencies, err := loadWordFrequencies(basePath + ".freq")
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Warnf("could not load %s word frequencies, segmenting by dictionary rank: %v", code, err)
+	}
+	verbalizer := languageVerbalizers[code]
+	if verbalizer != nil {
+		verbalizer.compile()
+	}
+	return &dictionaryLanguage{
+		code:           code,
+		pronunciations: pronunciations,
+		frequencies:    frequencies,
+		normalization:  normalization,
+		elisions:       languageElisions[code],
+		contexts:       languageContexts[code],
+		heteronyms:     languageHeteronyms[code],
+		verbalizer:     verbalizer,
+		g2p:            model,
+	}, problems, nil
+}
+
+func registerLanguages(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.txt"))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		code := strings.TrimSuffix(filepath.Base(path), ".txt")
+		language, problems, err := loadLanguage(code, path)
+		if err != nil {
+			log.Errorf("could not load %s dictionary: %v", code, err)
+			languageReports[code] = LanguageStatus{Code: code, Degraded: true, Problems: []string{err.Error()}}
+			continue
+		}
+
+		report := LanguageStatus{Code: code, Words: language.pronunciations.size(), Degraded: len(problems) > 0, Problems: []string{}}
+		for _, problem := range problems {
+			if len(report.Problems) == maxReportedProblems {
+				report.Problems = append(report.Problems, fmt.Sprintf("and %d more", len(problems)-maxReportedProblems))
+				break
+			}
+			report.Problems = append(report.Problems, problem.String())
+		}
+		languageReports[code] = report
+		if len(problems) > 0 {
+			for _, problem := range report.Problems {
+				log.Warn(problem)
+			}
+			if code == defaultLanguage {
+				return fmt.Errorf("default language %s has %d dictionary problems", code, len(problems))
+			}
+			log.Warnf("loaded %s dictionary in degraded state with %d problems", code, len(problems))
+		}
+
+		languages[code] = language
+		log.Infof("loaded %s dictionary with %d words", code, language.pronunciations.size())
+	}
+	if _, exists := languages[defaultLanguage]; !exists {
+		return fmt.Errorf("default language %s is not available in %s", defaultLanguage, dir)
+	}
+	return nil
+}
+
+func languageFor(code string) (Language, error) {
+	if code == "" {
+		code = defaultLanguage
+	}
+	if language, exists := languages[code]; exists {
+		return language, nil
+	}
+	for _, known := range slices.Sorted(maps.Keys(languages)) {
+		if strings.HasPrefix(known, code+"_") {
+			return languages[known], nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported language: %s", code)
+}
+
+func languageStatuses() []LanguageStatus {
+	statuses := []LanguageStatus{}
+	for _, code := range slices.Sorted(maps.Keys(languageReports)) {
+		statuses = append(statuses, languageReports[code])
+	}
+	return statuses
+}
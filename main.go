@@ -2,6 +2,8 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"crypto/subtle"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -13,11 +15,10 @@ import (
 	"math"
 	"net/http"
 	"os"
-	"path/filepath"
-	"regexp"
 	"slices"
 	"strings"
 	"time"
+	"unicode"
 	"unicode/utf8"
 
 	"github.com/bwmarrin/discordgo"
@@ -27,6 +28,7 @@ import (
 	"golang.org/x/image/font"
 	"golang.org/x/image/font/basicfont"
 	"golang.org/x/image/math/fixed"
+	"golang.org/x/text/unicode/norm"
 )
 
 var killed bool
@@ -39,7 +41,6 @@ type symbolMatch struct {
 	sizeY      int
 	centerX    int
 	centerY    int
-	disabled   bool
 }
 
 type ErrorResponse struct {
@@ -47,24 +48,50 @@ type ErrorResponse struct {
 }
 
 type DecodeRequest struct {
-	Type  string `json:"type"`
-	Text  string `json:"text"`
-	Image string `json:"image"`
+	Type         string  `json:"type"`
+	Text         string  `json:"text"`
+	Image        string  `json:"image"`
+	Alternatives int     `json:"alternatives"`
+	Recognizer   string  `json:"recognizer"`
+	Overlap      float64 `json:"overlap"`
 }
 
 type EncodeRequest struct {
-	Text string `json:"text"`
+	Text           string      `json:"text"`
+	Lang           string      `json:"lang"`
+	Unmapped       string      `json:"unmapped"`
+	Stress         string      `json:"stress"`
+	Output         string      `json:"output"`
+	Pronunciations map[int]int `json:"pronunciations"`
 }
 
 type DecodeResponse struct {
-	Phonetics string `json:"phonetics"`
-	AlienText string `json:"alien"`
+	Phonetics string         `json:"phonetics"`
+	AlienText string         `json:"alien"`
+	Letters   string         `json:"letters"`
+	Words     []DecodedWord  `json:"words"`
+	Tokens    []AlignedToken `json:"tokens"`
+	Detected  string         `json:"detected"`
 }
 
 type EncodeResponse struct {
-	Type  string `json:"type"`
-	Text  string `json:"text"`
-	Image string `json:"image"`
+	Type              string            `json:"type"`
+	Text              string            `json:"text"`
+	Image             string            `json:"image"`
+	Guessed           []string          `json:"guessed"`
+	Unmapped          []UnmappedSegment `json:"unmapped"`
+	Variants          []WordVariants    `json:"variants"`
+	Tokens            []AlignedToken    `json:"tokens"`
+	Output            string            `json:"output"`
+	TooLongForDiscord bool              `json:"tooLongForDiscord"`
+}
+
+type AlignedToken struct {
+	Source string `json:"source"`
+	IPA    string `json:"ipa"`
+	Glyphs string `json:"glyphs"`
+	Found  bool   `json:"found"`
+	Offset int    `json:"offset"`
 }
 
 func average(nums []int) int {
@@ -78,17 +105,6 @@ func average(nums []int) int {
 	return sum / len(nums)
 }
 
-func averageFloat(nums []float64) float64 {
-	if len(nums) == 0 {
-		return 0
-	}
-	sum := float64(0)
-	for _, num := range nums {
-		sum += num
-	}
-	return sum / float64(len(nums))
-}
-
 func prepareBinaryImage(imgData []byte) (gocv.Mat, error) {
 	imgMat, err := gocv.IMDecode(imgData, gocv.IMReadColor)
 	if err != nil || imgMat.Empty() {
@@ -258,119 +274,39 @@ func renderTextToPNG(text, fontPath string) (string, error) {
 	return encodedStr, nil
 }
 
-func readImageToSymbols(imgdata []byte) (string, error) {
+type ocrOptions struct {
+	recognizer string
+	overlap    float64
+}
 
-	inputGray, err := prepareBinaryImage(imgdata)
+func readImageToSymbols(ctx context.Context, imgdata []byte, options ocrOptions) (string, error) {
+	releaseSlot, err := acquireOCRSlot(ctx)
 	if err != nil {
-		log.Fatalf("Error preparing binary image: %v", err)
+		return "", err
 	}
-	defer inputGray.Close()
-	matches := []*symbolMatch{}
+	defer releaseSlot()
 
-	templates, err := filepath.Glob("./train/*.png")
+	inputGray, err := prepareBinaryImage(imgdata)
 	if err != nil {
 		return "", err
 	}
+	defer inputGray.Close()
 
-	if len(templates) == 0 {
-		fmt.Println("No template files found.")
+	bank, err := acquireTemplates()
+	if err != nil {
 		return "", err
 	}
+	defer bank.release()
 
-	scaleFound := false
-	foundScale := float64(1)
-	scales := []float64{}
-
-	for _, tmplFilename := range templates {
-		symbol := strings.TrimSuffix(strings.TrimPrefix(tmplFilename, "train/"), ".png")
-
-		tmpl := gocv.IMRead(tmplFilename, gocv.IMReadGrayScale)
-		if tmpl.Empty() {
-			continue
-		}
-		defer tmpl.Close()
-
-		bestScore := float32(-1.0)
-		var bestScale float64
-
-		scaleLower := 0.05
-		scaleUpper := 10.0
-		scaleStep := 0.1
-
-		if len(scales) > 5 {
-			avgLastScales := averageFloat(scales[len(scales)-3:])
-			if avgLastScales/scales[len(scales)-1] > 0.95 && avgLastScales/scales[len(scales)-1] < 1.05 {
-				scaleFound = true
-				foundScale = avgLastScales
-			}
-		}
-
-		if !scaleFound {
-			for range 3 {
-				for scale := scaleLower; scale <= scaleUpper; scale += scaleStep {
-					scaledTemplate := gocv.NewMat()
-					newWidth := int(float64(tmpl.Cols()) * scale)
-					newHeight := int(float64(tmpl.Rows()) * scale)
-					gocv.Resize(tmpl, &scaledTemplate, image.Point{X: newWidth, Y: newHeight}, 0, 0, gocv.InterpolationLinear)
-					if scaledTemplate.Cols() > inputGray.Cols() || scaledTemplate.Rows() > inputGray.Rows() {
-						scaledTemplate.Close()
-						continue
-					}
-					result := gocv.NewMat()
-					gocv.MatchTemplate(inputGray, scaledTemplate, &result, gocv.TmCcoeffNormed, gocv.NewMat())
-					_, maxVal, _, _ := gocv.MinMaxLoc(result)
-
-					if maxVal > bestScore {
-						bestScore = maxVal
-						bestScale = scale
-					}
-					result.Close()
-					scaledTemplate.Close()
-				}
-				scaleLower = max(0.1, bestScale-scaleStep)
-				scaleUpper = bestScale + scaleStep
-				scaleStep = scaleStep / 4
-			}
-		} else {
-			bestScale = foundScale
-		}
-
-		scales = append(scales, bestScale)
-		scaledTemplate := gocv.NewMat()
-		newWidth := int(float64(tmpl.Cols()) * bestScale)
-		newHeight := int(float64(tmpl.Rows()) * bestScale)
-		gocv.Resize(tmpl, &scaledTemplate, image.Point{X: newWidth, Y: newHeight}, 0, 0, gocv.InterpolationLinear)
-		defer scaledTemplate.Close()
-
-		if scaledTemplate.Cols() > inputGray.Cols() || scaledTemplate.Rows() > inputGray.Rows() {
-			continue
-		}
-
-		result := gocv.NewMat()
-		gocv.MatchTemplate(inputGray, scaledTemplate, &result, gocv.TmCcoeffNormed, gocv.NewMat())
-
-		_, bestScore, _, _ = gocv.MinMaxLoc(result)
-
-		baseThreshold := float32(0.68)
-		matchThreshold := max(baseThreshold, bestScore*0.85)
-
-		var matchLocations []*symbolMatch
-		for y := range result.Rows() {
-			for x := range result.Cols() {
-				val := result.GetFloatAt(y, x)
-				if val >= matchThreshold {
-					matchLocations = append(matchLocations, &symbolMatch{symbol: symbol, confidence: val, position: image.Pt(x, y), sizeX: newWidth, sizeY: newHeight, disabled: false})
-				}
-			}
-		}
-		result.Close()
-
-		matches = append(matches, matchLocations...)
+	var matches []*symbolMatch
+	if options.recognizer == recognizerComponents {
+		matches = bank.recognizeComponents(inputGray)
+	} else {
+		matches = bank.matchTemplates(inputGray, estimateScales(inputGray, bank))
 	}
 
 	rowPositions := []int{}
-	heights := []int{}
-	cols := map[int][]int{}
+	rowHeights := map[int][]int{}
 
 	for _, match := range matches {
 		ratio := float64(match.sizeX) / float64(max(match.sizeY, 1))
@@ -378,7 +314,6 @@ func readImageToSymbols(imgdata []byte) (string, error) {
 		centerY := match.position.Y + match.sizeY/2
 		match.centerX = match.position.X + match.sizeX/2
 		if isSquare {
-			heights = append(heights, match.sizeY)
 			found := false
 			for _, r := range rowPositions {
 				if math.Abs(float64(centerY)-float64(r)) < float64(match.sizeY)/4 {
@@ -390,15 +325,13 @@ func readImageToSymbols(imgdata []byte) (string, error) {
 			if !found {
 				match.centerY = centerY
 				rowPositions = append(rowPositions, centerY)
-				cols[centerY] = []int{}
 			}
+			rowHeights[match.centerY] = append(rowHeights[match.centerY], match.sizeY)
 		} else {
 			match.centerY = centerY
 		}
 	}
 
-	avgHeight := average(heights)
-
 	cleanedMatches := []*symbolMatch{}
 	for _, match := range matches {
 		found := false
@@ -409,65 +342,13 @@ func readImageToSymbols(imgdata []byte) (string, error) {
 				break
 			}
 		}
-		if !found || math.Abs(float64(avgHeight-match.sizeY)) > float64(match.sizeY)/4 {
-			match.disabled = true
+		if !found || math.Abs(float64(average(rowHeights[match.centerY])-match.sizeY)) > float64(match.sizeY)/4 {
 			continue
 		}
 		cleanedMatches = append(cleanedMatches, match)
 	}
 
-	for _, match := range cleanedMatches {
-		found := false
-		for _, x := range cols[match.centerY] {
-			if math.Abs(float64(match.centerX)-float64(x)) < float64(match.sizeX)/3 {
-				match.centerX = x
-				found = true
-				break
-			}
-		}
-		if !found {
-			cols[match.centerY] = append(cols[match.centerY], match.centerX)
-		}
-	}
-
-	toDisable := []*symbolMatch{}
-	sortedByCenter := map[int]map[int][]*symbolMatch{}
-	for _, match := range cleanedMatches {
-		if row, exists := sortedByCenter[match.centerY]; exists {
-			if cell, exists := row[match.centerX]; exists {
-				row[match.centerX] = append(cell, match)
-			} else {
-				row[match.centerX] = []*symbolMatch{match}
-			}
-		} else {
-			sortedByCenter[match.centerY] = map[int][]*symbolMatch{match.centerX: {match}}
-		}
-	}
-	for _, row := range sortedByCenter {
-		for _, cell := range row {
-			slices.SortStableFunc(cell, func(i, j *symbolMatch) int {
-				if float64(min(j.sizeX, i.sizeX))/float64(max(j.sizeX, i.sizeX)) < 0.25 {
-					return j.sizeX - i.sizeX
-				}
-				return int((j.confidence - i.confidence) * 1000)
-			})
-			for _, match := range cell[1:] {
-				match.disabled = true
-			}
-		}
-	}
-
-	for _, d := range toDisable {
-		d.disabled = true
-	}
-
-	matchedSymbols := []*symbolMatch{}
-	for _, match := range matches {
-		if match.disabled {
-			continue
-		}
-		matchedSymbols = append(matchedSymbols, match)
-	}
+	matchedSymbols := suppressNested(suppressOverlaps(cleanedMatches, options.overlap))
 
 	slices.SortStableFunc(matchedSymbols, func(i, j *symbolMatch) int {
 		if i.centerY != j.centerY {
@@ -494,8 +375,14 @@ func jsonResponse(w http.ResponseWriter, v any) {
 }
 
 func respondWithError(w http.ResponseWriter, err error) {
-	w.WriteHeader(http.StatusBadRequest)
-	jsonResponse(w, ErrorResponse{
+	respondWithStatus(w, http.StatusBadRequest, err)
+}
+
+func respondWithStatus(w http.ResponseWriter, status int, err error) {
+	enableCors(w)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ErrorResponse{
 		Error: err.Error(),
 	})
 }
@@ -504,59 +391,150 @@ func enableCors(w http.ResponseWriter) {
 	w.Header().Set("Access-Control-Allow-Origin", "https://hvzlien.sylvie.fyi")
 	w.Header().Set("Access-Control-Allow-Credentials", "true")
 	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, PATCH, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
 }
 
 func translateAlienToSounds(alienText string) string {
-	for a, p := range lookup {
-		alienText = strings.ReplaceAll(alienText, a, p)
-	}
-	return alienText
+	return activeAlphabet().decode.translate(alienText)
 }
 
-func encodeAlienFromEnglish(humanText string) string {
-	lowercase := strings.ToLower(humanText)
-	re := regexp.MustCompile(`[\w']+|[^\s\w]+|[\s]+`)
-	tokens := re.FindAllString(lowercase, -1)
+type encodeResult struct {
+	alien    string
+	guessed  []string
+	unmapped []UnmappedSegment
+	variants []WordVariants
+	tokens   []AlignedToken
+}
 
-	for i, token := range tokens {
-		if ipa, exists := ipaTable[token]; exists {
-			tokens[i] = ipa
+type encodeOptions struct {
+	unmapped       string
+	stress         string
+	pronunciations map[int]int
+}
+
+type encodeToken struct {
+	word   string
+	source string
+	offset int
+}
+
+func encodeAlien(humanText string, language Language, options encodeOptions) encodeResult {
+	a := activeAlphabet()
+	result := encodeResult{guessed: []string{}, unmapped: []UnmappedSegment{}, variants: []WordVariants{}, tokens: []AlignedToken{}}
+	var alien strings.Builder
+
+	humanText = norm.NFC.String(humanText)
+	tokens := []encodeToken{}
+	for _, segment := range language.Verbalize(humanText) {
+		for _, bounds := range language.Tokenize(segment.text) {
+			token := encodeToken{word: canonicalWord(segment.text[bounds[0]:bounds[1]])}
+			if segment.expanded {
+				token.source, token.offset = segment.source, segment.offset
+			} else {
+				token.source, token.offset = segment.text[bounds[0]:bounds[1]], segment.offset+bounds[0]
+			}
+			tokens = append(tokens, token)
 		}
 	}
-
-	sounds := strings.Join(tokens, "")
-	for c, r := range secondaryIPAMapping {
-		sounds = strings.ReplaceAll(sounds, c, r)
+	words := make([]string, len(tokens))
+	for i, token := range tokens {
+		words[i] = token.word
 	}
 
-	alien := sounds
-	for a, p := range reverseLookup {
-		alien = strings.ReplaceAll(alien, a, p)
-	}
-	return alien
-}
+	wordIndex := -1
+	for i, current := range tokens {
+		source := current.source
+		token := current.word
+		if strings.IndexFunc(token, isWordRune) >= 0 {
+			wordIndex++
+		}
+
+		sounds := token
+		found := false
+		if variants := language.Variants(token); len(variants) > 0 {
+			found = true
+			chosen := language.Choose(token, neighbourWord(words, i, -1), neighbourWord(words, i, 1))
+			if choice, exists := options.pronunciations[wordIndex]; exists && choice >= 0 && choice < len(variants) {
+				chosen = choice
+			}
+			sounds = variants[chosen]
+			if distinctSounds(variants) > 1 {
+				result.variants = append(result.variants, WordVariants{
+					Word:    source,
+					Index:   wordIndex,
+					Offset:  current.offset,
+					Options: variants,
+					Chosen:  chosen,
+				})
+			}
+		} else if ipa, ok := language.Guess(token); ok {
+			sounds = ipa
+			if !slices.Contains(result.guessed, token) {
+				result.guessed = append(result.guessed, token)
+			}
+		}
+		sounds = applyStressMode(sounds, options.stress)
+		aligned := AlignedToken{Source: source, Offset: current.offset, Found: found}
+		if found || slices.Contains(result.guessed, token) {
+			aligned.IPA = sounds
+		}
+		sounds = a.normalize.translate(language.Normalize(sounds))
+		glyphStart := alien.Len()
 
-func encodeAlienFromFrench(humanText string) string {
-	lowercase := strings.ToLower(humanText)
-	re := regexp.MustCompile(`[\w']+|[^\s\w]+|[\s]+`)
-	tokens := re.FindAllString(lowercase, -1)
+		pending := ""
+		flush := func() {
+			if pending == "" {
+				return
+			}
+			result.unmapped = append(result.unmapped, UnmappedSegment{
+				Segment:    pending,
+				Word:       source,
+				WordOffset: current.offset,
+				Offset:     alien.Len(),
+			})
+			alien.WriteString(substituteUnmapped(pending, options.unmapped))
+			pending = ""
+		}
+		for len(sounds) > 0 {
+			if glyph, size, ok := a.encode.match(sounds); ok {
+				flush()
+				alien.WriteString(glyph)
+				sounds = sounds[size:]
+				continue
+			}
+			r, size := utf8.DecodeRuneInString(sounds)
+			if unicode.IsSpace(r) {
+				flush()
+				alien.WriteRune(r)
+			} else {
+				pending += string(r)
+			}
+			sounds = sounds[size:]
+		}
+		flush()
 
-	for i, token := range tokens {
-		if ipa, exists := frenchTable[token]; exists {
-			tokens[i] = ipa
+		if strings.TrimSpace(token) != "" {
+			aligned.Glyphs = alien.String()[glyphStart:]
+			result.tokens = append(result.tokens, aligned)
 		}
 	}
 
-	sounds := strings.Join(tokens, "")
-	for c, r := range secondaryIPAMapping {
-		sounds = strings.ReplaceAll(sounds, c, r)
+	result.alien = alien.String()
+	return result
+}
+
+func degradedNote(code string) string {
+	if !languageReports[code].Degraded {
+		return ""
 	}
+	return fmt.Sprintf("\n-# the %s dictionary loaded with problems, some words may be missing", code)
+}
 
-	alien := sounds
-	for a, p := range reverseLookup {
-		alien = strings.ReplaceAll(alien, a, p)
+func guessedNote(guessed []string) string {
+	if len(guessed) == 0 {
+		return ""
 	}
-	return alien
+	return fmt.Sprintf("\n-# guessed pronunciation for: %s", strings.Join(guessed, ", "))
 }
 
 func Decode(w http.ResponseWriter, r *http.Request) {
@@ -570,31 +548,71 @@ func Decode(w http.ResponseWriter, r *http.Request) {
 		jsonResponse(w, DecodeResponse{Phonetics: "translations currently disabled", AlienText: "translations currently disabled"})
 		return
 	}
-	var translated string
-	switch decodeRequest.Type {
-	case "text":
-		translated = translateAlienToSounds(decodeRequest.Text)
-		jsonResponse(w, DecodeResponse{Phonetics: translated, AlienText: decodeRequest.Text})
-	case "image":
-		imgBytes, err := base64.StdEncoding.DecodeString(decodeRequest.Image)
+	recognizer, err := parseRecognizer(decodeRequest.Recognizer)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+	overlap, err := parseOverlapThreshold(decodeRequest.Overlap)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+	format := decodeRequest.Type
+	switch format {
+	case decodeAuto:
+		format, err = detectDecodeFormat(decodeRequest)
+		if err != nil {
+			respondWithError(w, err)
+			return
+		}
+	case decodeText:
+		format = detectAlienFormat(decodeRequest.Text)
+	case decodeImage:
+		format = detectedImage
+	default:
+		respondWithError(w, fmt.Errorf("not a valid decode request type"))
+		log.Infof("got invalid decode request type: %s", decodeRequest.Type)
+		return
+	}
+
+	var alien, translated string
+	switch format {
+	case detectedImage:
+		encoded := decodeRequest.Image
+		if encoded == "" {
+			encoded = decodeRequest.Text
+		}
+		imgBytes, err := decodeImageData(encoded)
 		if err != nil {
 			respondWithError(w, err)
 			return
 		}
-		symbols, err := readImageToSymbols(imgBytes)
+		alien, err = readImageToSymbols(r.Context(), imgBytes, ocrOptions{recognizer: recognizer, overlap: overlap})
 		if err != nil {
 			respondWithError(w, err)
 			return
 		}
-		translated = translateAlienToSounds(symbols)
-		jsonResponse(w, DecodeResponse{Phonetics: translated, AlienText: symbols})
+		translated = translateRecognizedSymbols(alien)
+	case detectedIPA:
+		a := activeAlphabet()
+		alien = a.encode.translate(a.normalize.translate(norm.NFC.String(decodeRequest.Text)))
+		translated = translateAlienToSounds(alien)
 	default:
-		respondWithError(w, fmt.Errorf("not a valid decode request type"))
-		log.Infof("got invalid decode request type: %s", decodeRequest.Type)
-		return
+		alien = parseAlienText(decodeRequest.Text)
+		translated = translateAlienToSounds(alien)
 	}
+	words := translateSoundsToEnglish(translated, decodeRequest.Alternatives)
+	jsonResponse(w, DecodeResponse{
+		Phonetics: translated,
+		AlienText: alien,
+		Letters:   decodedWordsToLetters(words),
+		Words:     words,
+		Tokens:    alignDecodedWords(translated, words),
+		Detected:  format,
+	})
 
-	log.Infof("got decode request type: %s, with content %s", decodeRequest.Type, translated)
+	log.Infof("got decode request type: %s (%s), with content %s", decodeRequest.Type, format, translated)
 }
 
 func EncodeText(w http.ResponseWriter, r *http.Request) {
@@ -608,7 +626,37 @@ func EncodeText(w http.ResponseWriter, r *http.Request) {
 		jsonResponse(w, EncodeResponse{Text: "translations currently disabled"})
 		return
 	}
-	jsonResponse(w, EncodeResponse{Text: encodeAlienFromEnglish(encodeRequest.Text)})
+	language, err := languageFor(encodeRequest.Lang)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+	policy, err := parseUnmappedPolicy(encodeRequest.Unmapped)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+	stress, err := parseStressMode(encodeRequest.Stress)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+	output, err := parseOutputFormat(encodeRequest.Output)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+	encoded := encodeAlien(encodeRequest.Text, language, encodeOptions{unmapped: policy, stress: stress, pronunciations: encodeRequest.Pronunciations})
+	text := formatAlien(encoded.alien, output)
+	jsonResponse(w, EncodeResponse{
+		Text:              text,
+		Guessed:           encoded.guessed,
+		Unmapped:          encoded.unmapped,
+		Variants:          encoded.variants,
+		Tokens:            encoded.tokens,
+		Output:            output,
+		TooLongForDiscord: exceedsDiscordLimit(formatAlien(encoded.alien, outputEmoji)),
+	})
 	log.Infof("got text encode request for: %s", encodeRequest.Text)
 }
 
@@ -623,25 +671,106 @@ func EncodeImage(w http.ResponseWriter, r *http.Request) {
 		jsonResponse(w, EncodeResponse{Text: "translations currently disabled"})
 		return
 	}
-	translated := encodeAlienFromEnglish(encodeRequest.Text)
-	imgBase64, err := renderTextToPNG(translated, "alien.ttf")
+	language, err := languageFor(encodeRequest.Lang)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+	policy, err := parseUnmappedPolicy(encodeRequest.Unmapped)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+	stress, err := parseStressMode(encodeRequest.Stress)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+	encoded := encodeAlien(encodeRequest.Text, language, encodeOptions{unmapped: policy, stress: stress, pronunciations: encodeRequest.Pronunciations})
+	imgBase64, err := renderTextToPNG(encoded.alien, "alien.ttf")
 	if err != nil {
 		respondWithError(w, err)
 		return
 	}
-	jsonResponse(w, EncodeResponse{Image: imgBase64})
+	jsonResponse(w, EncodeResponse{Image: imgBase64, Guessed: encoded.guessed, Unmapped: encoded.unmapped, Variants: encoded.variants, Tokens: encoded.tokens})
 	log.Infof("got image encode request for: %s", encodeRequest.Text)
 }
 
-func alienToEmojis(alienText string, incudeDiscriminator bool) string {
-	for symbol, mapping := range emojiNames {
-		if incudeDiscriminator {
-			alienText = strings.ReplaceAll(alienText, symbol, fmt.Sprintf("<:%s:%s>", mapping[0], mapping[1]))
-		} else {
-			alienText = strings.ReplaceAll(alienText, symbol, fmt.Sprintf(":%s:", mapping[0]))
+func Languages(w http.ResponseWriter, r *http.Request) {
+	jsonResponse(w, languageStatuses())
+}
+
+func isAdminRequest(r *http.Request) bool {
+	token := os.Getenv(adminTokenEnv)
+	return token != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+token)) == 1
+}
+
+func Dictionary(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodOptions:
+		enableCors(w)
+		return
+	case http.MethodGet:
+		word := r.URL.Query().Get("word")
+		if word == "" {
+			jsonResponse(w, userWords.entries())
+			return
+		}
+		language, err := languageFor(r.URL.Query().Get("lang"))
+		if err != nil {
+			respondWithError(w, err)
+			return
+		}
+		ipa, exists := userWords.lookup(language.Code(), canonicalWord(word))
+		if !exists {
+			respondWithStatus(w, http.StatusNotFound, fmt.Errorf("no user pronunciation for %s", word))
+			return
 		}
+		jsonResponse(w, UserWord{Word: canonicalWord(word), IPA: ipa, Lang: language.Code()})
+		return
+	}
+
+	if !isAdminRequest(r) {
+		respondWithStatus(w, http.StatusUnauthorized, fmt.Errorf("admin token required"))
+		return
+	}
+	switch r.Method {
+	case http.MethodPost, http.MethodPut:
+		var entry UserWord
+		if err := json.NewDecoder(r.Body).Decode(&entry); err != nil {
+			respondWithError(w, err)
+			return
+		}
+		entry, err := userWords.set(entry)
+		if err != nil {
+			respondWithError(w, err)
+			return
+		}
+		log.Infof("user dictionary: %s (%s) is now /%s/", entry.Word, entry.Lang, entry.IPA)
+		jsonResponse(w, entry)
+	case http.MethodDelete:
+		word := r.URL.Query().Get("word")
+		removed, err := userWords.remove(r.URL.Query().Get("lang"), word)
+		if err != nil {
+			respondWithError(w, err)
+			return
+		}
+		if !removed {
+			respondWithStatus(w, http.StatusNotFound, fmt.Errorf("no user pronunciation for %s", word))
+			return
+		}
+		log.Infof("user dictionary: removed %s", word)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		respondWithStatus(w, http.StatusMethodNotAllowed, fmt.Errorf("method not allowed: %s", r.Method))
 	}
-	return alienText
+}
+
+func alienToEmojis(alienText string, incudeDiscriminator bool) string {
+	if incudeDiscriminator {
+		return activeAlphabet().emoji.translate(alienText)
+	}
+	return activeAlphabet().shortcode.translate(alienText)
 }
 
 func DiscordEnglishToAlienEmojis(s *discordgo.Session, i *discordgo.InteractionCreate) {
@@ -660,10 +789,30 @@ func DiscordEnglishToAlienEmojis(s *discordgo.Session, i *discordgo.InteractionC
 			},
 		})
 
-		translated := encodeAlienFromEnglish(msgText)
-		emojified := alienToEmojis(translated, true)
-		if len(emojified) > 2000 {
-			emojified = fmt.Sprintf("output too long by %d chars", len(emojified)-2000)
+		code := defaultLanguage
+		if option, ok := optionMap["lang"]; ok {
+			code = option.StringValue()
+		}
+		language, err := languageFor(code)
+		if err != nil {
+			msg := err.Error()
+			s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+				Content: &msg,
+			})
+			return
+		}
+		policy := defaultUnmappedPolicy
+		if option, ok := optionMap["unmapped"]; ok {
+			policy = option.StringValue()
+		}
+		stress := defaultStressMode
+		if option, ok := optionMap["stress"]; ok {
+			stress = option.StringValue()
+		}
+		encoded := encodeAlien(msgText, language, encodeOptions{unmapped: policy, stress: stress})
+		emojified := alienToEmojis(encoded.alien, true) + guessedNote(encoded.guessed) + unmappedNote(encoded.unmapped, policy) + degradedNote(language.Code())
+		if exceedsDiscordLimit(emojified) {
+			emojified = fmt.Sprintf("output too long by %d chars", utf8.RuneCountInString(emojified)-discordMessageLimit)
 		}
 		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
 			Content: &emojified,
@@ -695,10 +844,26 @@ func DiscordEnglishToAlienEmojisFrench(s *discordgo.Session, i *discordgo.Intera
 			},
 		})
 
-		translated := encodeAlienFromFrench(msgText)
-		emojified := alienToEmojis(translated, true)
-		if len(emojified) > 2000 {
-			emojified = fmt.Sprintf("output too long by %d chars", len(emojified)-2000)
+		language, err := languageFor("fr_FR")
+		if err != nil {
+			msg := "french dictionary is not loaded"
+			s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+				Content: &msg,
+			})
+			return
+		}
+		policy := defaultUnmappedPolicy
+		if option, ok := optionMap["unmapped"]; ok {
+			policy = option.StringValue()
+		}
+		stress := defaultStressMode
+		if option, ok := optionMap["stress"]; ok {
+			stress = option.StringValue()
+		}
+		encoded := encodeAlien(msgText, language, encodeOptions{unmapped: policy, stress: stress})
+		emojified := alienToEmojis(encoded.alien, true) + guessedNote(encoded.guessed) + unmappedNote(encoded.unmapped, policy) + degradedNote(language.Code())
+		if exceedsDiscordLimit(emojified) {
+			emojified = fmt.Sprintf("output too long by %d chars", utf8.RuneCountInString(emojified)-discordMessageLimit)
 		}
 		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
 			Content: &emojified,
@@ -730,7 +895,28 @@ func DiscordEnglishToAlienEmojisRaw(s *discordgo.Session, i *discordgo.Interacti
 			},
 		})
 
-		translated := encodeAlienFromEnglish(msgText)
+		code := defaultLanguage
+		if option, ok := optionMap["lang"]; ok {
+			code = option.StringValue()
+		}
+		language, err := languageFor(code)
+		if err != nil {
+			msg := err.Error()
+			s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+				Content: &msg,
+			})
+			return
+		}
+		policy := defaultUnmappedPolicy
+		if option, ok := optionMap["unmapped"]; ok {
+			policy = option.StringValue()
+		}
+		stress := defaultStressMode
+		if option, ok := optionMap["stress"]; ok {
+			stress = option.StringValue()
+		}
+		encoded := encodeAlien(msgText, language, encodeOptions{unmapped: policy, stress: stress})
+		translated := encoded.alien + guessedNote(encoded.guessed) + unmappedNote(encoded.unmapped, policy) + degradedNote(language.Code())
 
 		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
 			Content: &translated,
@@ -762,10 +948,11 @@ func DiscordAlienUnicodeToEnglish(s *discordgo.Session, i *discordgo.Interaction
 			},
 		})
 
-		translated := translateAlienToSounds(msgText)
+		translated := translateAlienToSounds(parseAlienText(msgText))
+		msg := fmt.Sprintf("`%s`\n%s", translated, decodedWordsToLetters(translateSoundsToEnglish(translated, 1)))
 
 		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
-			Content: &translated,
+			Content: &msg,
 		})
 		return
 	}
@@ -798,6 +985,60 @@ func AliveHttp(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	})
 }
 
+func ReloadAlphabet(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	msg := "alphabet reloaded"
+	if err := reloadAlphabet(); err != nil {
+		log.Errorf("could not reload alphabet: %v", err)
+		msg = fmt.Sprintf("could not reload alphabet, keeping the old one: %v", err)
+	}
+	if len(msg) > 2000 {
+		msg = msg[:2000]
+	}
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: msg,
+		},
+	})
+}
+
+func DiscordLearnWord(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	options := i.ApplicationCommandData().Options
+	optionMap := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(options))
+	for _, opt := range options {
+		optionMap[opt.Name] = opt
+	}
+
+	entry := UserWord{Lang: defaultLanguage}
+	if option, ok := optionMap["word"]; ok {
+		entry.Word = option.StringValue()
+	}
+	if option, ok := optionMap["pronunciation"]; ok {
+		entry.IPA = option.StringValue()
+	}
+	if option, ok := optionMap["lang"]; ok {
+		entry.Lang = option.StringValue()
+	}
+
+	var msg string
+	if saved, err := userWords.set(entry); err != nil {
+		msg = fmt.Sprintf("could not learn %s: %v", entry.Word, err)
+	} else {
+		log.Infof("user dictionary: %s (%s) is now /%s/", saved.Word, saved.Lang, saved.IPA)
+		encoded := encodeAlien(saved.Word, languages[saved.Lang], encodeOptions{unmapped: defaultUnmappedPolicy, stress: defaultStressMode})
+		msg = fmt.Sprintf("learned %s as `%s` (%s): %s", saved.Word, saved.IPA, saved.Lang, alienToEmojis(encoded.alien, true))
+	}
+	if len(msg) > 2000 {
+		msg = msg[:2000]
+	}
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: msg,
+		},
+	})
+}
+
 func DiscordEmojiToEnglish(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	options := i.ApplicationCommandData().Options
 	optionMap := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(options))
@@ -814,13 +1055,11 @@ func DiscordEmojiToEnglish(s *discordgo.Session, i *discordgo.InteractionCreate)
 			},
 		})
 
-		for symbol, emoji := range emojiNames {
-			msgText = strings.ReplaceAll(msgText, fmt.Sprintf("<:%s:%s>", emoji[0], emoji[1]), symbol)
-		}
-		translated := translateAlienToSounds(msgText)
+		translated := translateAlienToSounds(parseAlienText(msgText))
+		msg := fmt.Sprintf("`%s`\n%s", translated, decodedWordsToLetters(translateSoundsToEnglish(translated, 1)))
 
 		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
-			Content: &translated,
+			Content: &msg,
 		})
 		return
 	}
@@ -833,6 +1072,11 @@ func DiscordEmojiToEnglish(s *discordgo.Session, i *discordgo.InteractionCreate)
 	})
 }
 
+func gneepMessage(symbols string) string {
+	translated := translateRecognizedSymbols(symbols)
+	return fmt.Sprintf("`%s`\n%s", translated, decodedWordsToLetters(translateSoundsToEnglish(translated, 1)))
+}
+
 func DiscordDecodeImage(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	options := i.ApplicationCommandData().Options
 	optionMap := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(options))
@@ -871,7 +1115,7 @@ func DiscordDecodeImage(s *discordgo.Session, i *discordgo.InteractionCreate) {
 			},
 		})
 
-		symbols, err := readImageToSymbols(imgBytes)
+		symbols, err := readImageToSymbols(context.Background(), imgBytes, ocrOptions{recognizer: defaultRecognizer, overlap: defaultOverlapThreshold})
 		if err != nil {
 			msg := "failed to parse symbols"
 			s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
@@ -879,9 +1123,7 @@ func DiscordDecodeImage(s *discordgo.Session, i *discordgo.InteractionCreate) {
 			})
 			return
 		}
-		translated := translateAlienToSounds(symbols)
-
-		msg := fmt.Sprintf("`%s`", translated)
+		msg := gneepMessage(symbols)
 		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
 			Content: &msg,
 		})
@@ -896,6 +1138,35 @@ func DiscordDecodeImage(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	})
 }
 
+var unmappedOption = &discordgo.ApplicationCommandOption{
+	Type:        discordgo.ApplicationCommandOptionString,
+	Name:        "unmapped",
+	Description: "what to do with sounds that have no glyph",
+	Choices: []*discordgo.ApplicationCommandOptionChoice{
+		{Name: "keep", Value: unmappedKeep},
+		{Name: "drop", Value: unmappedDrop},
+		{Name: "nearest glyph", Value: unmappedNearest},
+	},
+}
+
+var stressOption = &discordgo.ApplicationCommandOption{
+	Type:        discordgo.ApplicationCommandOptionString,
+	Name:        "stress",
+	Description: "which stress marks to write",
+	Choices: []*discordgo.ApplicationCommandOptionChoice{
+		{Name: "full", Value: stressFull},
+		{Name: "primary only", Value: stressPrimary},
+		{Name: "none", Value: stressNone},
+		{Name: "word-initial only", Value: stressWordInitial},
+	},
+}
+
+var langOption = &discordgo.ApplicationCommandOption{
+	Type:        discordgo.ApplicationCommandOptionString,
+	Name:        "lang",
+	Description: "dictionary to encode with, e.g. en_US",
+}
+
 func runDiscord() {
 	discord, err := discordgo.New("Bot <BotTokenGoHere>")
 	if err != nil {
@@ -905,6 +1176,7 @@ func runDiscord() {
 	discord.Open()
 	defer discord.Close()
 	guildID := "762409528779210823"
+	adminPermissions := int64(discordgo.PermissionManageServer)
 	commands := []*discordgo.ApplicationCommand{
 		{
 			Name:        "gneep",
@@ -930,6 +1202,9 @@ func runDiscord() {
 					Description: "text to encode",
 					Required:    true,
 				},
+				unmappedOption,
+				stressOption,
+				langOption,
 			},
 		},
 		{
@@ -943,6 +1218,8 @@ func runDiscord() {
 					Description: "text to encode (french)",
 					Required:    true,
 				},
+				unmappedOption,
+				stressOption,
 			},
 		},
 		{
@@ -956,6 +1233,9 @@ func runDiscord() {
 					Description: "text to encode",
 					Required:    true,
 				},
+				unmappedOption,
+				stressOption,
+				langOption,
 			},
 		},
 		{
@@ -984,6 +1264,27 @@ func runDiscord() {
 				},
 			},
 		},
+		{
+			Name:                     "gnarp-learn",
+			Description:              "teaches the encoder how to pronounce a word",
+			DefaultMemberPermissions: &adminPermissions,
+			Options: []*discordgo.ApplicationCommandOption{
+
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "word",
+					Description: "word to learn",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "pronunciation",
+					Description: "pronunciation in ipa, e.g. ˈɡnɑrp",
+					Required:    true,
+				},
+				langOption,
+			},
+		},
 		{
 			Name:        "kill",
 			Description: "disables http server",
@@ -992,16 +1293,23 @@ func runDiscord() {
 			Name:        "alive",
 			Description: "enables http server",
 		},
+		{
+			Name:                     "reload",
+			Description:              "reloads the alphabet definition file",
+			DefaultMemberPermissions: &adminPermissions,
+		},
 	}
 	commandHandlers := map[string]func(s *discordgo.Session, i *discordgo.InteractionCreate){
-		"gneep":     DiscordDecodeImage,
-		"gnarp":     DiscordEnglishToAlienEmojis,
-		"gnarp-fr":  DiscordEnglishToAlienEmojisFrench,
-		"gnarp-raw": DiscordEnglishToAlienEmojisRaw,
-		"glorp":     DiscordAlienUnicodeToEnglish,
-		"glarp":     DiscordEmojiToEnglish,
-		"kill":      KillHttp,
-		"alive":     AliveHttp,
+		"gneep":       DiscordDecodeImage,
+		"gnarp":       DiscordEnglishToAlienEmojis,
+		"gnarp-fr":    DiscordEnglishToAlienEmojisFrench,
+		"gnarp-raw":   DiscordEnglishToAlienEmojisRaw,
+		"glorp":       DiscordAlienUnicodeToEnglish,
+		"glarp":       DiscordEmojiToEnglish,
+		"kill":        KillHttp,
+		"alive":       AliveHttp,
+		"reload":      ReloadAlphabet,
+		"gnarp-learn": DiscordLearnWord,
 	}
 	discord.AddHandler(func(s *discordgo.Session, r *discordgo.Ready) {
 		log.Printf("Logged in as: %v#%v", s.State.User.Username, s.State.User.Discriminator)
@@ -1030,19 +1338,37 @@ func runDiscord() {
 }
 
 func main() {
-	reverseLookup = map[string]string{}
-	for a, p := range lookup {
-		reverseLookup[p] = a
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if err := initAlphabet(); err != nil {
+		log.Fatal(err)
+	}
+	if err := initTemplateBank(); err != nil {
+		log.Fatal(err)
 	}
 
 	http.HandleFunc("/api/v1/decode", Decode)
 	http.HandleFunc("/api/v1/encode/text", EncodeText)
 	http.HandleFunc("/api/v1/encode/image", EncodeImage)
+	http.HandleFunc("/api/v1/dictionary", Dictionary)
+	http.HandleFunc("/api/v1/languages", Languages)
 	fs := http.FileServer(http.Dir("./frontend/dist"))
 	http.Handle("/", fs)
 
-	loadIPA("ipa/en_US.txt")
-	loadFrench("ipa/fr_FR.txt")
+	if err := registerLanguages(dictionaryDir); err != nil {
+		log.Fatal(err)
+	}
+	storePhoneticIndex(languages[defaultLanguage])
+	dictionary, err := loadUserDictionary(userDictionaryPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	userWords = dictionary
 
 	go runDiscord()
 	fmt.Println("starting server...")
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"image"
+	"slices"
+)
+
+const defaultOverlapThreshold = 0.3
+
+const nestedWidthRatio = 4
+const nestedCoverage = 0.9
+
+func parseOverlapThreshold(threshold float64) (float64, error) {
+	if threshold == 0 {
+		return defaultOverlapThreshold, nil
+	}
+	if threshold < 0 || threshold > 1 {
+		return 0, fmt.Errorf("overlap threshold must be between 0 and 1: %v", threshold)
+	}
+	return threshold, nil
+}
+
+func (m *symbolMatch) bounds() image.Rectangle {
+	return image.Rectangle{Min: m.position, Max: m.position.Add(image.Pt(m.sizeX, m.sizeY))}
+}
+
+func area(r image.Rectangle) int {
+	return r.Dx() * r.Dy()
+}
+
+func intersectionOverUnion(a image.Rectangle, b image.Rectangle) float64 {
+	intersection := area(a.Intersect(b))
+	if intersection == 0 {
+		return 0
+	}
+	return float64(intersection) / float64(area(a)+area(b)-intersection)
+}
+
+func suppressOverlaps(matches []*symbolMatch, threshold float64) []*symbolMatch {
+	ranked := slices.Clone(matches)
+	slices.SortStableFunc(ranked, func(i, j *symbolMatch) int {
+		return cmp.Compare(j.confidence, i.confidence)
+	})
+	kept := []*symbolMatch{}
+	for _, match := range ranked {
+		if !slices.ContainsFunc(kept, func(k *symbolMatch) bool {
+			return intersectionOverUnion(k.bounds(), match.bounds()) > threshold
+		}) {
+			kept = append(kept, match)
+		}
+	}
+	return kept
+}
+
+// A dot template matching a ring's dot has too small an IoU for suppressOverlaps.
+func suppressNested(matches []*symbolMatch) []*symbolMatch {
+	return slices.DeleteFunc(slices.Clone(matches), func(match *symbolMatch) bool {
+		inner := match.bounds()
+		return slices.ContainsFunc(matches, func(outer *symbolMatch) bool {
+			return outer.sizeX > nestedWidthRatio*match.sizeX &&
+				float64(area(inner.Intersect(outer.bounds()))) >= nestedCoverage*float64(area(inner))
+		})
+	})
+}
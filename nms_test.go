@@ -0,0 +1,185 @@
+package main
+
+import (
+	"image"
+	"slices"
+	"testing"
+)
+
+func testMatch(symbol string, confidence float32, x int, y int, width int, height int) *symbolMatch {
+	return &symbolMatch{symbol: symbol, confidence: confidence, position: image.Pt(x, y), sizeX: width, sizeY: height}
+}
+
+func matchSymbols(matches []*symbolMatch) []string {
+	symbols := []string{}
+	for _, match := range matches {
+		symbols = append(symbols, match.symbol)
+	}
+	slices.Sort(symbols)
+	return symbols
+}
+
+func TestIntersectionOverUnion(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b image.Rectangle
+		want float64
+	}{
+		{name: "identical", a: image.Rect(0, 0, 10, 10), b: image.Rect(0, 0, 10, 10), want: 1},
+		{name: "disjoint", a: image.Rect(0, 0, 10, 10), b: image.Rect(20, 0, 30, 10), want: 0},
+		{name: "touching", a: image.Rect(0, 0, 10, 10), b: image.Rect(10, 0, 20, 10), want: 0},
+		{name: "half overlap", a: image.Rect(0, 0, 10, 10), b: image.Rect(5, 0, 15, 10), want: 50.0 / 150},
+		{name: "contained", a: image.Rect(0, 0, 10, 10), b: image.Rect(0, 0, 5, 10), want: 0.5},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := intersectionOverUnion(test.a, test.b); got != test.want {
+				t.Errorf("intersectionOverUnion(%v, %v) = %v, want %v", test.a, test.b, got, test.want)
+			}
+		})
+	}
+}
+
+func TestSuppressOverlaps(t *testing.T) {
+	tests := []struct {
+		name      string
+		matches   []*symbolMatch
+		threshold float64
+		want      []string
+	}{
+		{
+			name:      "iou just above the threshold",
+			matches:   []*symbolMatch{testMatch("a", 0.9, 0, 0, 100, 100), testMatch("b", 0.8, 53, 0, 100, 100)},
+			threshold: 0.3,
+			want:      []string{"a"},
+		},
+		{
+			name:      "iou just below the threshold",
+			matches:   []*symbolMatch{testMatch("a", 0.9, 0, 0, 100, 100), testMatch("b", 0.8, 55, 0, 100, 100)},
+			threshold: 0.3,
+			want:      []string{"a", "b"},
+		},
+		{
+			name:      "straddles a column bucket edge",
+			matches:   []*symbolMatch{testMatch("a", 0.8, 0, 0, 100, 100), testMatch("a", 0.9, 34, 0, 100, 100)},
+			threshold: defaultOverlapThreshold,
+			want:      []string{"a"},
+		},
+		{
+			name:      "straddles a row bucket edge",
+			matches:   []*symbolMatch{testMatch("a", 0.9, 0, 0, 100, 100), testMatch("b", 0.7, 0, 26, 100, 100)},
+			threshold: defaultOverlapThreshold,
+			want:      []string{"a"},
+		},
+		{
+			name: "across templates the most confident wins",
+			matches: []*symbolMatch{
+				testMatch("a", 0.7, 0, 0, 100, 100),
+				testMatch("b", 0.95, 2, 1, 100, 100),
+				testMatch("c", 0.8, 1, 2, 100, 100),
+			},
+			threshold: defaultOverlapThreshold,
+			want:      []string{"b"},
+		},
+		{
+			name: "a row of neighbours is kept",
+			matches: []*symbolMatch{
+				testMatch("a", 0.8, 0, 0, 100, 100),
+				testMatch("b", 0.8, 105, 0, 100, 100),
+				testMatch("c", 0.8, 210, 0, 100, 100),
+			},
+			threshold: defaultOverlapThreshold,
+			want:      []string{"a", "b", "c"},
+		},
+		{
+			name:      "empty",
+			matches:   []*symbolMatch{},
+			threshold: defaultOverlapThreshold,
+			want:      []string{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := matchSymbols(suppressOverlaps(test.matches, test.threshold)); !slices.Equal(got, test.want) {
+				t.Errorf("kept %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSuppressOverlapsKeepsTheMostConfident(t *testing.T) {
+	best := testMatch("a", 0.9, 2, 1, 100, 100)
+	kept := suppressOverlaps([]*symbolMatch{testMatch("a", 0.8, 0, 0, 100, 100), best, testMatch("a", 0.85, 4, 0, 100, 100)}, defaultOverlapThreshold)
+	if len(kept) != 1 || kept[0] != best {
+		t.Errorf("kept %v, want only the 0.9 match", kept)
+	}
+}
+
+func TestSuppressNested(t *testing.T) {
+	tests := []struct {
+		name    string
+		matches []*symbolMatch
+		want    []string
+	}{
+		{
+			name:    "dot inside a ring",
+			matches: []*symbolMatch{testMatch("ring", 0.7, 0, 0, 100, 100), testMatch("dot", 0.95, 40, 70, 20, 30)},
+			want:    []string{"ring"},
+		},
+		{
+			name:    "dot beside a ring",
+			matches: []*symbolMatch{testMatch("ring", 0.7, 0, 0, 100, 100), testMatch("dot", 0.95, 110, 70, 20, 30)},
+			want:    []string{"dot", "ring"},
+		},
+		{
+			name:    "dot half outside a ring",
+			matches: []*symbolMatch{testMatch("ring", 0.7, 0, 0, 100, 100), testMatch("dot", 0.95, 90, 70, 20, 30)},
+			want:    []string{"dot", "ring"},
+		},
+		{
+			name:    "exactly four times wider does not nest",
+			matches: []*symbolMatch{testMatch("wide", 0.7, 0, 0, 40, 40), testMatch("narrow", 0.9, 10, 10, 10, 10)},
+			want:    []string{"narrow", "wide"},
+		},
+		{
+			name:    "more than four times wider nests",
+			matches: []*symbolMatch{testMatch("wide", 0.7, 0, 0, 41, 41), testMatch("narrow", 0.9, 10, 10, 10, 10)},
+			want:    []string{"wide"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := matchSymbols(suppressNested(test.matches)); !slices.Equal(got, test.want) {
+				t.Errorf("kept %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseOverlapThreshold(t *testing.T) {
+	tests := []struct {
+		threshold float64
+		want      float64
+		fails     bool
+	}{
+		{threshold: 0, want: defaultOverlapThreshold},
+		{threshold: 0.5, want: 0.5},
+		{threshold: 1, want: 1},
+		{threshold: -0.1, fails: true},
+		{threshold: -1, fails: true},
+		{threshold: 1.01, fails: true},
+		{threshold: 2, fails: true},
+	}
+	for _, test := range tests {
+		got, err := parseOverlapThreshold(test.threshold)
+		if test.fails {
+			if err == nil {
+				t.Errorf("parseOverlapThreshold(%v) = %v, want an error", test.threshold, got)
+			}
+			continue
+		}
+		if err != nil || got != test.want {
+			t.Errorf("parseOverlapThreshold(%v) = %v, %v, want %v", test.threshold, got, err, test.want)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"strconv"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"gocv.io/x/gocv"
+)
+
+const ocrJobsEnv = "GNARP_OCR_JOBS"
+const defaultOCRJobs = 2
+
+var ocrSlots = make(chan struct{}, ocrJobLimit())
+
+func ocrJobLimit() int {
+	value := os.Getenv(ocrJobsEnv)
+	if value == "" {
+		return defaultOCRJobs
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 1 {
+		log.Warnf("ignoring %s=%q, using %d", ocrJobsEnv, value, defaultOCRJobs)
+		return defaultOCRJobs
+	}
+	return limit
+}
+
+func acquireOCRSlot(ctx context.Context) (func(), error) {
+	select {
+	case ocrSlots <- struct{}{}:
+		return func() { <-ocrSlots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (b *templateBank) matchTemplates(input gocv.Mat, scales []float64) []*symbolMatch {
+	type matchJob struct {
+		tmpl  *glyphTemplate
+		scale float64
+	}
+	jobs := []matchJob{}
+	for _, tmpl := range b.templates {
+		for _, scale := range scales {
+			jobs = append(jobs, matchJob{tmpl: tmpl, scale: scale})
+		}
+	}
+
+	results := make([][]*symbolMatch, len(jobs))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range min(runtime.GOMAXPROCS(0), len(jobs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				results[i] = b.matchTemplate(input, jobs[i].tmpl, jobs[i].scale)
+			}
+		}()
+	}
+	for i := range jobs {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	matches := []*symbolMatch{}
+	for _, result := range results {
+		matches = append(matches, result...)
+	}
+	return matches
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"os"
+	"testing"
+)
+
+var exampleImages = []string{"example.png", "text2.png", "text3.png", "Trans_mission_2.jpg"}
+
+func BenchmarkReadImageToSymbols(b *testing.B) {
+	if err := initAlphabet(); err != nil {
+		b.Fatal(err)
+	}
+	if err := initTemplateBank(); err != nil {
+		b.Fatal(err)
+	}
+	for _, path := range exampleImages {
+		imgdata, err := os.ReadFile(path)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for _, recognizer := range []string{recognizerTemplate, recognizerComponents} {
+			options := ocrOptions{recognizer: recognizer, overlap: defaultOverlapThreshold}
+			b.Run(path+"/"+recognizer+"/warm", func(b *testing.B) {
+				for range b.N {
+					if _, err := readImageToSymbols(context.Background(), imgdata, options); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+			b.Run(path+"/"+recognizer+"/cold", func(b *testing.B) {
+				for range b.N {
+					b.StopTimer()
+					if err := initTemplateBank(); err != nil {
+						b.Fatal(err)
+					}
+					b.StartTimer()
+					if _, err := readImageToSymbols(context.Background(), imgdata, options); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkLoadTemplateBank(b *testing.B) {
+	if err := initAlphabet(); err != nil {
+		b.Fatal(err)
+	}
+	for range b.N {
+		bank, err := loadTemplateBank(activeAlphabet().templates)
+		if err != nil {
+			b.Fatal(err)
+		}
+		bank.close()
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+const (
+	outputGlyphs    = "glyphs"
+	outputEmoji     = "emoji"
+	outputShortcode = "shortcode"
+	outputIPA       = "ipa"
+)
+
+const defaultOutputFormat = outputGlyphs
+
+const discordMessageLimit = 2000
+
+func parseOutputFormat(format string) (string, error) {
+	switch format {
+	case "":
+		return defaultOutputFormat, nil
+	case outputGlyphs, outputEmoji, outputShortcode, outputIPA:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown output format: %s", format)
+	}
+}
+
+func formatAlien(alien string, format string) string {
+	switch format {
+	case outputEmoji:
+		return alienToEmojis(alien, true)
+	case outputShortcode:
+		return alienToEmojis(alien, false)
+	case outputIPA:
+		return translateAlienToSounds(alien)
+	default:
+		return alien
+	}
+}
+
+func exceedsDiscordLimit(text string) bool {
+	return utf8.RuneCountInString(text) > discordMessageLimit
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTooLongForDiscordUsesEmojiLength(t *testing.T) {
+	if err := registerTestLanguages(); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		text   string
+		output string
+		want   bool
+	}{
+		{text: "hello", output: outputGlyphs, want: false},
+		{text: strings.Repeat("hello ", 60), output: outputGlyphs, want: true},
+		{text: strings.Repeat("hello ", 60), output: outputIPA, want: true},
+		{text: strings.Repeat("hello ", 60), output: outputEmoji, want: true},
+	}
+	for _, test := range tests {
+		body, _ := json.Marshal(EncodeRequest{Text: test.text, Output: test.output})
+		recorder := httptest.NewRecorder()
+		EncodeText(recorder, httptest.NewRequest(http.MethodPost, "/api/v1/encode/text", strings.NewReader(string(body))))
+		var response EncodeResponse
+		if err := json.NewDecoder(recorder.Body).Decode(&response); err != nil {
+			t.Fatal(err)
+		}
+		if response.TooLongForDiscord != test.want {
+			t.Errorf("%d characters as %s: tooLongForDiscord = %v, want %v", len(test.text), test.output, response.TooLongForDiscord, test.want)
+		}
+	}
+}
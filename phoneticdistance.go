@@ -0,0 +1,56 @@
+package main
+
+var vowelSounds = map[rune]bool{
+	'i': true, 'ɪ': true, 'ɛ': true, 'a': true, 'æ': true, 'ə': true, 'ɐ': true,
+	'u': true, 'o': true, 'ɜ': true, 'ʊ': true, 'ʌ': true, 'e': true, 'ɑ': true,
+}
+
+var voicingPairs = map[rune]rune{
+	'p': 'b', 'b': 'p',
+	't': 'd', 'd': 't',
+	'k': 'ɡ', 'ɡ': 'k',
+	'f': 'v', 'v': 'f',
+	's': 'z', 'z': 's',
+	'θ': 'ð', 'ð': 'θ',
+	'ʃ': 'ʒ', 'ʒ': 'ʃ',
+}
+
+func substitutionCost(a, b rune) float64 {
+	switch {
+	case a == b:
+		return 0
+	case vowelSounds[a] && vowelSounds[b]:
+		return 0.5
+	case voicingPairs[a] == b:
+		return 0.5
+	default:
+		return 1
+	}
+}
+
+func indelCost(r rune) float64 {
+	if vowelSounds[r] {
+		return 0.75
+	}
+	return 1
+}
+
+func phoneticDistance(a, b []rune) float64 {
+	previous := make([]float64, len(b)+1)
+	current := make([]float64, len(b)+1)
+	for j := 1; j <= len(b); j++ {
+		previous[j] = previous[j-1] + indelCost(b[j-1])
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = previous[0] + indelCost(a[i-1])
+		for j := 1; j <= len(b); j++ {
+			current[j] = min(
+				previous[j]+indelCost(a[i-1]),
+				current[j-1]+indelCost(b[j-1]),
+				previous[j-1]+substitutionCost(a[i-1], b[j-1]),
+			)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"slices"
+
+	"gocv.io/x/gocv"
+)
+
+const (
+	recognizerTemplate   = "template"
+	recognizerComponents = "components"
+)
+
+const defaultRecognizer = recognizerTemplate
+
+const minComponentArea = 4
+const minComponentScore = float32(0.5)
+
+func parseRecognizer(recognizer string) (string, error) {
+	switch recognizer {
+	case "":
+		return defaultRecognizer, nil
+	case recognizerTemplate, recognizerComponents:
+		return recognizer, nil
+	default:
+		return "", fmt.Errorf("unknown recognizer: %s", recognizer)
+	}
+}
+
+func glyphBlobs(binary gocv.Mat) []image.Rectangle {
+	inverted := gocv.NewMat()
+	defer inverted.Close()
+	gocv.BitwiseNot(binary, &inverted)
+
+	labels, stats, centroids := gocv.NewMat(), gocv.NewMat(), gocv.NewMat()
+	defer labels.Close()
+	defer stats.Close()
+	defer centroids.Close()
+	count := gocv.ConnectedComponentsWithStats(inverted, &labels, &stats, &centroids)
+
+	blobs := []image.Rectangle{}
+	for label := 1; label < count; label++ {
+		if int(stats.GetIntAt(label, int(gocv.CC_STAT_AREA))) < minComponentArea {
+			continue
+		}
+		left := int(stats.GetIntAt(label, int(gocv.CC_STAT_LEFT)))
+		top := int(stats.GetIntAt(label, int(gocv.CC_STAT_TOP)))
+		width := int(stats.GetIntAt(label, int(gocv.CC_STAT_WIDTH)))
+		height := int(stats.GetIntAt(label, int(gocv.CC_STAT_HEIGHT)))
+		blobs = append(blobs, image.Rect(left, top, left+width, top+height))
+	}
+
+	for merged := true; merged; {
+		merged = false
+		for i := 0; i < len(blobs); i++ {
+			for j := i + 1; j < len(blobs); j++ {
+				if blobs[i].Overlaps(blobs[j]) {
+					blobs[i] = blobs[i].Union(blobs[j])
+					blobs = slices.Delete(blobs, j, j+1)
+					merged = true
+					j--
+				}
+			}
+		}
+	}
+	return blobs
+}
+
+func (t *glyphTemplate) correlation(blob gocv.Mat) float32 {
+	ink := t.mat.Region(t.ink)
+	defer ink.Close()
+	scaled := gocv.NewMat()
+	defer scaled.Close()
+	gocv.Resize(ink, &scaled, image.Pt(blob.Cols(), blob.Rows()), 0, 0, gocv.InterpolationArea)
+
+	result := gocv.NewMat()
+	defer result.Close()
+	gocv.MatchTemplate(blob, scaled, &result, gocv.TmCcoeffNormed, gocv.NewMat())
+	_, score, _, _ := gocv.MinMaxLoc(result)
+	return score
+}
+
+func (b *templateBank) recognizeComponents(input gocv.Mat) []*symbolMatch {
+	matches := []*symbolMatch{}
+	for _, box := range glyphBlobs(input) {
+		blob := input.Region(box)
+		var best *glyphTemplate
+		bestScore := minComponentScore
+		for _, tmpl := range b.templates {
+			aspect := (float64(box.Dx()) / float64(box.Dy())) / (float64(tmpl.ink.Dx()) / float64(tmpl.ink.Dy()))
+			if aspect < 0.67 || aspect > 1.5 {
+				continue
+			}
+			if score := tmpl.correlation(blob); score > bestScore {
+				best, bestScore = tmpl, score
+			}
+		}
+		blob.Close()
+		if best == nil {
+			continue
+		}
+
+		scale := (float64(box.Dx())/float64(best.ink.Dx()) + float64(box.Dy())/float64(best.ink.Dy())) / 2
+		size := best.size(scale)
+		position := box.Min.Sub(image.Pt(int(float64(best.ink.Min.X)*scale), int(float64(best.ink.Min.Y)*scale)))
+		matches = append(matches, &symbolMatch{symbol: best.symbol, confidence: bestScore, position: position, sizeX: size.X, sizeY: size.Y})
+	}
+	return matches
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode"
+)
+
+type WordCandidate struct {
+	Word  string  `json:"word"`
+	Score float64 `json:"score"`
+}
+
+type DecodedWord struct {
+	Phonetics  string          `json:"phonetics"`
+	Candidates []WordCandidate `json:"candidates"`
+}
+
+type indexedSpelling struct {
+	word  string
+	rank  int
+	count int
+}
+
+type phoneticDictionary struct {
+	index        map[string][]string
+	costs        map[string]float64
+	keysByLength map[int][][]rune
+	maxKeyLength int
+}
+
+type lazyPhonetics struct {
+	once           sync.Once
+	pronunciations pronunciationTable
+	frequencies    map[string]int
+	dictionary     *phoneticDictionary
+}
+
+var currentPhonetics atomic.Pointer[lazyPhonetics]
+
+type phoneticIndexes []*phoneticDictionary
+
+const defaultAlternatives = 3
+const maxAlternatives = 20
+
+func phoneticKey(ipa string) string {
+	return activeAlphabet().phoneticKey.translate(ipa)
+}
+
+func storePhoneticIndex(language Language) {
+	currentPhonetics.Store(&lazyPhonetics{pronunciations: language.Dictionary(), frequencies: language.Frequencies()})
+}
+
+func (l *lazyPhonetics) load() *phoneticDictionary {
+	l.once.Do(func() {
+		l.dictionary = buildPhoneticIndex(l.pronunciations, l.frequencies)
+	})
+	return l.dictionary
+}
+
+func buildPhoneticIndex(pronunciations pronunciationTable, frequencies map[string]int) *phoneticDictionary {
+	candidates := map[string][]indexedSpelling{}
+	for word, options := range pronunciations.words() {
+		for rank, option := range options {
+			key := phoneticKey(option)
+			if key == "" {
+				continue
+			}
+			candidates[key] = append(candidates[key], indexedSpelling{word: word, rank: rank, count: frequencies[word]})
+		}
+	}
+
+	maxCount := 0
+	for _, count := range frequencies {
+		maxCount = max(maxCount, count)
+	}
+	dictionary := &phoneticDictionary{
+		index:        make(map[string][]string, len(candidates)),
+		costs:        make(map[string]float64, len(candidates)),
+		keysByLength: map[int][][]rune{},
+	}
+	for key, spellings := range candidates {
+		slices.SortFunc(spellings, func(i, j indexedSpelling) int {
+			if i.rank != j.rank {
+				return i.rank - j.rank
+			}
+			iPlain, jPlain := isPlainWord(i.word), isPlainWord(j.word)
+			if iPlain != jPlain {
+				if iPlain {
+					return -1
+				}
+				return 1
+			}
+			if i.count != j.count {
+				return j.count - i.count
+			}
+			if len(i.word) != len(j.word) {
+				return len(i.word) - len(j.word)
+			}
+			return strings.Compare(i.word, j.word)
+		})
+		words := []string{}
+		cost := math.Inf(1)
+		for _, spelling := range spellings {
+			if !slices.Contains(words, spelling.word) {
+				words = append(words, spelling.word)
+			}
+			cost = min(cost, segmentCost(spelling.rank, spelling.count, maxCount))
+		}
+		dictionary.index[key] = words
+		dictionary.costs[key] = cost
+		keyRunes := []rune(key)
+		dictionary.keysByLength[len(keyRunes)] = append(dictionary.keysByLength[len(keyRunes)], keyRunes)
+		dictionary.maxKeyLength = max(dictionary.maxKeyLength, len(keyRunes))
+	}
+	return dictionary
+}
+
+func activePhonetics() phoneticIndexes {
+	indexes := phoneticIndexes{}
+	if dictionary := userWords.phonetics.Load(); dictionary != nil {
+		indexes = append(indexes, dictionary)
+	}
+	if bundled := currentPhonetics.Load(); bundled != nil {
+		indexes = append(indexes, bundled.load())
+	}
+	return indexes
+}
+
+func (indexes phoneticIndexes) known(key string) bool {
+	return slices.ContainsFunc(indexes, func(dictionary *phoneticDictionary) bool {
+		_, exists := dictionary.index[key]
+		return exists
+	})
+}
+
+func (indexes phoneticIndexes) wordCost(key string) (float64, bool) {
+	cost, found := math.Inf(1), false
+	for _, dictionary := range indexes {
+		if wordCost, exists := dictionary.costs[key]; exists {
+			cost, found = min(cost, wordCost), true
+		}
+	}
+	return cost, found
+}
+
+func (indexes phoneticIndexes) maxKeyLength() int {
+	length := 0
+	for _, dictionary := range indexes {
+		length = max(length, dictionary.maxKeyLength)
+	}
+	return length
+}
+
+func (indexes phoneticIndexes) rankCandidates(key string, alternatives int) []WordCandidate {
+	candidates := []WordCandidate{}
+	for _, dictionary := range indexes {
+		for _, candidate := range dictionary.rankCandidates(key, alternatives) {
+			if !slices.ContainsFunc(candidates, func(c WordCandidate) bool { return c.Word == candidate.Word }) {
+				candidates = append(candidates, candidate)
+			}
+		}
+	}
+	slices.SortStableFunc(candidates, func(i, j WordCandidate) int {
+		return cmp.Compare(j.Score, i.Score)
+	})
+	return candidates[:min(len(candidates), alternatives)]
+}
+
+func isPlainWord(word string) bool {
+	for _, r := range word {
+		if !unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func translateSoundsToEnglish(sounds string, alternatives int) []DecodedWord {
+	if alternatives <= 0 {
+		alternatives = defaultAlternatives
+	}
+	alternatives = min(alternatives, maxAlternatives)
+
+	indexes := activePhonetics()
+	words := []DecodedWord{}
+	for _, phonetics := range strings.Fields(sounds) {
+		words = append(words, DecodedWord{
+			Phonetics:  phonetics,
+			Candidates: indexes.rankCandidates(phoneticKey(phonetics), alternatives),
+		})
+	}
+	return words
+}
+
+func (dictionary *phoneticDictionary) rankCandidates(key string, alternatives int) []WordCandidate {
+	candidates := []WordCandidate{}
+	keyRunes := []rune(key)
+	if len(keyRunes) == 0 {
+		return candidates
+	}
+	maxDistance := max(1, float64(len(keyRunes))/3)
+
+	type scoredKey struct {
+		key   string
+		score float64
+	}
+	scored := []scoredKey{}
+	for length := max(1, len(keyRunes)-int(maxDistance)); length <= len(keyRunes)+int(maxDistance); length++ {
+		for _, other := range dictionary.keysByLength[length] {
+			distance := phoneticDistance(keyRunes, other)
+			if distance > maxDistance {
+				continue
+			}
+			scored = append(scored, scoredKey{
+				key:   string(other),
+				score: 1 - distance/float64(max(len(keyRunes), len(other))),
+			})
+		}
+	}
+	slices.SortStableFunc(scored, func(i, j scoredKey) int {
+		if i.score != j.score {
+			if i.score > j.score {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(i.key, j.key)
+	})
+
+	for _, match := range scored {
+		for _, word := range dictionary.index[match.key] {
+			candidates = append(candidates, WordCandidate{Word: word, Score: match.score})
+			if len(candidates) >= alternatives {
+				return candidates
+			}
+		}
+	}
+	return candidates
+}
+
+func decodedWordsToLetters(words []DecodedWord) string {
+	letters := make([]string, len(words))
+	for i, word := range words {
+		if len(word.Candidates) > 0 {
+			letters[i] = word.Candidates[0].Word
+		} else {
+			letters[i] = word.Phonetics
+		}
+	}
+	return strings.Join(letters, " ")
+}
+
+func alignDecodedWords(sounds string, words []DecodedWord) []AlignedToken {
+	a := activeAlphabet()
+	tokens := make([]AlignedToken, len(words))
+	cursor := 0
+	for i, word := range words {
+		offset := cursor + strings.Index(sounds[cursor:], word.Phonetics)
+		cursor = offset + len(word.Phonetics)
+		tokens[i] = AlignedToken{
+			Source: word.Phonetics,
+			IPA:    word.Phonetics,
+			Glyphs: a.encode.translate(a.normalize.translate(word.Phonetics)),
+			Offset: offset,
+		}
+		if len(word.Candidates) > 0 {
+			tokens[i].Source = word.Candidates[0].Word
+			tokens[i].Found = word.Candidates[0].Score == 1
+		}
+	}
+	return tokens
+}
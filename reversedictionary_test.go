@@ -0,0 +1,93 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func testLanguage(t *testing.T) Language {
+	t.Helper()
+	if err := registerTestLanguages(); err != nil {
+		t.Fatal(err)
+	}
+	return languages[defaultLanguage]
+}
+
+func candidateWords(candidates []WordCandidate) []string {
+	words := []string{}
+	for _, candidate := range candidates {
+		words = append(words, candidate.Word)
+	}
+	return words
+}
+
+func TestRankCandidatesHomophones(t *testing.T) {
+	dictionary := buildPhoneticIndex(testLanguage(t).Dictionary(), nil)
+	tests := []struct {
+		ipa  string
+		want []string
+	}{
+		{ipa: "ˈkæts", want: []string{"cats", "kats", "katz", "cat's"}},
+		{ipa: "ˈðɛɹ", want: []string{"their", "there", "they're"}},
+		{ipa: "ˈðɛr", want: []string{"their", "there", "they're"}},
+	}
+	for _, test := range tests {
+		t.Run(test.ipa, func(t *testing.T) {
+			candidates := dictionary.rankCandidates(phoneticKey(test.ipa), len(test.want))
+			if got := candidateWords(candidates); !slices.Equal(got, test.want) {
+				t.Errorf("candidates are %q, want %q", got, test.want)
+			}
+			for _, candidate := range candidates {
+				if candidate.Score != 1 {
+					t.Errorf("%s scores %v, want an exact match", candidate.Word, candidate.Score)
+				}
+			}
+		})
+	}
+}
+
+func TestRankCandidatesNearMisses(t *testing.T) {
+	testLanguage(t)
+	dictionary := buildPhoneticIndex(mapTable{"cats": {"ˈkæts"}, "scats": {"ˈskæts"}, "cods": {"ˈkɑdz"}, "kit": {"ˈkɪt"}}, nil)
+	tests := []struct {
+		name  string
+		ipa   string
+		want  []string
+		score float64
+	}{
+		{name: "exact match first", ipa: "ˈkæts", want: []string{"cats", "scats"}, score: 1},
+		{name: "stress is ignored", ipa: "kæts", want: []string{"cats", "scats"}, score: 1},
+		{name: "voicing costs half", ipa: "ˈkæds", want: []string{"cats", "cods"}, score: 0.875},
+		{name: "vowel change costs half", ipa: "ˈkɑts", want: []string{"cats", "cods"}, score: 0.875},
+		{name: "closest spelling first", ipa: "ˈkædz", want: []string{"cods", "cats"}, score: 0.875},
+		{name: "insertion is further than a vowel change", ipa: "ˈkɛts", want: []string{"cats"}, score: 0.875},
+		{name: "too far to match", ipa: "ˈmʌŋk", want: []string{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			candidates := dictionary.rankCandidates(phoneticKey(test.ipa), 2)
+			if got := candidateWords(candidates); !slices.Equal(got, test.want) {
+				t.Fatalf("candidates are %q, want %q", got, test.want)
+			}
+			if len(candidates) > 0 && candidates[0].Score != test.score {
+				t.Errorf("%s scores %v, want %v", candidates[0].Word, candidates[0].Score, test.score)
+			}
+			if !slices.IsSortedFunc(candidates, func(i, j WordCandidate) int {
+				return int((j.Score - i.Score) * 1000)
+			}) {
+				t.Errorf("candidates are not sorted by score: %+v", candidates)
+			}
+		})
+	}
+}
+
+func TestRankCandidatesLimit(t *testing.T) {
+	testLanguage(t)
+	dictionary := buildPhoneticIndex(mapTable{"cats": {"ˈkæts"}, "kats": {"ˈkæts"}, "katz": {"ˈkæts"}}, nil)
+	if got := dictionary.rankCandidates(phoneticKey("ˈkæts"), 2); len(got) != 2 {
+		t.Errorf("asked for 2 alternatives, got %d", len(got))
+	}
+	if got := dictionary.rankCandidates("", 2); len(got) != 0 {
+		t.Errorf("empty key gives %+v", got)
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"image"
+	"math"
+	"slices"
+	"strings"
+
+	"gocv.io/x/gocv"
+)
+
+const scaleReferenceGlyphs = "☂☄"
+const minReferenceScore = float32(0.6)
+
+type scaleCandidate struct {
+	scale float64
+	score float32
+}
+
+func componentHeights(binary gocv.Mat) []int {
+	inverted := gocv.NewMat()
+	defer inverted.Close()
+	gocv.BitwiseNot(binary, &inverted)
+
+	labels, stats, centroids := gocv.NewMat(), gocv.NewMat(), gocv.NewMat()
+	defer labels.Close()
+	defer stats.Close()
+	defer centroids.Close()
+	count := gocv.ConnectedComponentsWithStats(inverted, &labels, &stats, &centroids)
+
+	heights := []int{}
+	for label := 1; label < count; label++ {
+		height := int(stats.GetIntAt(label, int(gocv.CC_STAT_HEIGHT)))
+		area := int(stats.GetIntAt(label, int(gocv.CC_STAT_AREA)))
+		if height >= 4 && area >= 8 {
+			heights = append(heights, height)
+		}
+	}
+	return heights
+}
+
+// Glyphs are drawn from several strokes, so each size is its tallest component.
+func textHeights(heights []int) []int {
+	heights = slices.Clone(heights)
+	slices.Sort(heights)
+	slices.Reverse(heights)
+	groups := []int{}
+	for i, height := range heights {
+		if i == 0 || float64(heights[i-1])/float64(height) > 1.3 {
+			groups = append(groups, height)
+		}
+	}
+	return groups
+}
+
+func referenceTemplates(bank *templateBank) []*glyphTemplate {
+	references := []*glyphTemplate{}
+	for _, tmpl := range bank.templates {
+		if strings.Contains(scaleReferenceGlyphs, tmpl.symbol) {
+			references = append(references, tmpl)
+		}
+	}
+	if len(references) == 0 {
+		return bank.templates
+	}
+	return references
+}
+
+func (b *templateBank) bestScale(input gocv.Mat, tmpl *glyphTemplate, lower float64, upper float64, steps int) scaleCandidate {
+	best := scaleCandidate{score: -1}
+	ratio := math.Pow(upper/lower, 1/float64(max(steps-1, 1)))
+	for i, scale := 0, lower; i < steps; i, scale = i+1, scale*ratio {
+		size := tmpl.size(scale)
+		if size.X < 1 || size.Y < 1 || size.X > input.Cols() || size.Y > input.Rows() {
+			continue
+		}
+		scaled, release := b.resized(tmpl, scale)
+		result := gocv.NewMat()
+		gocv.MatchTemplate(input, scaled, &result, gocv.TmCcoeffNormed, gocv.NewMat())
+		_, score, _, _ := gocv.MinMaxLoc(result)
+		result.Close()
+		release()
+		if score > best.score {
+			best = scaleCandidate{scale: scale, score: score}
+		}
+	}
+	return best
+}
+
+func estimateScales(input gocv.Mat, bank *templateBank) []float64 {
+	references := referenceTemplates(bank)
+	candidates := []scaleCandidate{}
+	for _, height := range textHeights(componentHeights(input)) {
+		best := scaleCandidate{score: -1}
+		for _, tmpl := range references {
+			estimate := float64(height) / float64(tmpl.ink.Dy())
+			if candidate := bank.bestScale(input, tmpl, estimate*0.6, estimate*1.4, 13); candidate.score > best.score {
+				best = candidate
+			}
+		}
+		if best.score >= minReferenceScore {
+			candidates = append(candidates, best)
+		}
+	}
+
+	if len(candidates) == 0 {
+		best := scaleCandidate{score: -1}
+		for _, tmpl := range references {
+			if candidate := bank.bestScale(input, tmpl, 0.05, 10, 80); candidate.score > best.score {
+				best = candidate
+			}
+		}
+		if best.score < 0 {
+			return nil
+		}
+		candidates = append(candidates, best)
+	}
+
+	slices.SortFunc(candidates, func(i, j scaleCandidate) int {
+		return int((j.score - i.score) * 1000)
+	})
+	scales := []float64{}
+	for _, candidate := range candidates {
+		if !slices.ContainsFunc(scales, func(scale float64) bool {
+			return math.Abs(scale-candidate.scale)/scale < 0.1
+		}) {
+			scales = append(scales, candidate.scale)
+		}
+	}
+	return scales
+}
+
+func (b *templateBank) matchTemplate(input gocv.Mat, tmpl *glyphTemplate, scale float64) []*symbolMatch {
+	best := b.bestScale(input, tmpl, scale/1.1, scale*1.1, 9)
+	if best.score < 0 {
+		return nil
+	}
+	size := tmpl.size(best.scale)
+	scaled, release := b.resized(tmpl, best.scale)
+	result := gocv.NewMat()
+	defer result.Close()
+	gocv.MatchTemplate(input, scaled, &result, gocv.TmCcoeffNormed, gocv.NewMat())
+	release()
+
+	baseThreshold := float32(0.68)
+	matchThreshold := max(baseThreshold, best.score*0.85)
+
+	var matchLocations []*symbolMatch
+	for y := range result.Rows() {
+		for x := range result.Cols() {
+			val := result.GetFloatAt(y, x)
+			if val >= matchThreshold {
+				matchLocations = append(matchLocations, &symbolMatch{symbol: tmpl.symbol, confidence: val, position: image.Pt(x, y), sizeX: size.X, sizeY: size.Y})
+			}
+		}
+	}
+	return matchLocations
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	segmentWordCost       = 1.0
+	segmentVariantCost    = 0.5
+	segmentRarityCost     = 1.0
+	segmentUnstressedCost = 0.2
+	segmentInnerStress    = 0.5
+	segmentUnknownCost    = 5.0
+)
+
+func loadWordFrequencies(path string) (map[string]int, error) {
+	filedata, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	frequencies := map[string]int{}
+	for i, line := range strings.Split(string(filedata), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		chunks := strings.Split(line, "\t")
+		if len(chunks) != 2 {
+			return nil, fmt.Errorf("%s:%d: expected word<TAB>count, found %d fields", path, i+1, len(chunks))
+		}
+		count, err := strconv.Atoi(strings.TrimSpace(chunks[1]))
+		if err != nil || count < 1 {
+			return nil, fmt.Errorf("%s:%d: count %q is not a positive integer", path, i+1, chunks[1])
+		}
+		frequencies[canonicalWord(strings.TrimSpace(chunks[0]))] += count
+	}
+	return frequencies, nil
+}
+
+// Words cost -log of their frequency relative to the most common word, scaled to segmentRarityCost.
+func segmentCost(rank int, count int, maxCount int) float64 {
+	cost := segmentWordCost + float64(rank)*segmentVariantCost
+	if maxCount > 1 {
+		cost += segmentRarityCost * math.Log(float64(maxCount)/float64(max(count, 1))) / math.Log(float64(maxCount))
+	}
+	return cost
+}
+
+type phonemeUnit struct {
+	text     string
+	key      string
+	stressed bool
+}
+
+func splitPhonemeUnits(run string) []phonemeUnit {
+	units := []phonemeUnit{}
+	pending := ""
+	for _, r := range run {
+		if r == 'ˈ' || r == 'ˌ' {
+			pending += string(r)
+			continue
+		}
+		units = append(units, phonemeUnit{
+			text:     pending + string(r),
+			key:      phoneticKey(string(r)),
+			stressed: pending != "",
+		})
+		pending = ""
+	}
+	if pending != "" && len(units) > 0 {
+		units[len(units)-1].text += pending
+	}
+	return units
+}
+
+func (indexes phoneticIndexes) segmentPhonemeRun(run string) []string {
+	units := splitPhonemeUnits(run)
+	if len(units) == 0 {
+		return nil
+	}
+
+	costs := make([]float64, len(units)+1)
+	previous := make([]int, len(units)+1)
+	known := make([]bool, len(units)+1)
+	for i := 1; i <= len(units); i++ {
+		costs[i] = math.Inf(1)
+	}
+
+	maxKeyLength := indexes.maxKeyLength()
+	for end := 1; end <= len(units); end++ {
+		key := ""
+		innerStress := 0
+		for start := end - 1; start >= 0 && end-start <= maxKeyLength; start-- {
+			key = units[start].key + key
+			if start < end-1 && units[start+1].stressed {
+				innerStress++
+			}
+			wordCost, exists := indexes.wordCost(phoneticKey(key))
+			if !exists {
+				continue
+			}
+			cost := costs[start] + wordCost + float64(innerStress)*segmentInnerStress
+			if !units[start].stressed {
+				cost += segmentUnstressedCost
+			}
+			if cost < costs[end] {
+				costs[end] = cost
+				previous[end] = start
+				known[end] = true
+			}
+		}
+		if cost := costs[end-1] + segmentUnknownCost; cost < costs[end] {
+			costs[end] = cost
+			previous[end] = end - 1
+			known[end] = false
+		}
+	}
+
+	words := []string{}
+	unknownRun := ""
+	for end := len(units); end > 0; end = previous[end] {
+		text := ""
+		for _, unit := range units[previous[end]:end] {
+			text += unit.text
+		}
+		if !known[end] {
+			unknownRun = text + unknownRun
+			continue
+		}
+		if unknownRun != "" {
+			words = append(words, unknownRun)
+			unknownRun = ""
+		}
+		words = append(words, text)
+	}
+	if unknownRun != "" {
+		words = append(words, unknownRun)
+	}
+
+	for i, j := 0, len(words)-1; i < j; i, j = i+1, j-1 {
+		words[i], words[j] = words[j], words[i]
+	}
+	return words
+}
+
+func segmentSounds(sounds string) string {
+	indexes := activePhonetics()
+	words := []string{}
+	for _, chunk := range strings.Fields(sounds) {
+		if indexes.known(phoneticKey(chunk)) {
+			words = append(words, chunk)
+			continue
+		}
+		words = append(words, indexes.segmentPhonemeRun(chunk)...)
+	}
+	return strings.Join(words, " ")
+}
+
+func translateRecognizedSymbols(symbols string) string {
+	return segmentSounds(translateAlienToSounds(symbols))
+}
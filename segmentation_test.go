@@ -0,0 +1,118 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSegmentPhonemeRun(t *testing.T) {
+	testLanguage(t)
+	pronunciations := mapTable{
+		"cat": {"ˈkæt"}, "cats": {"ˈkæts"}, "sat": {"ˈsæt"}, "at": {"ˈæt"},
+		"a": {"ə"}, "name": {"ˈneɪm"}, "an": {"ən"}, "aim": {"ˈeɪm"},
+	}
+	tests := []struct {
+		name        string
+		run         string
+		frequencies map[string]int
+		want        []string
+	}{
+		{name: "stressed words", run: "ˈkætˈsæt", want: []string{"ˈkæt", "ˈsæt"}},
+		{name: "unknown sounds stay together", run: "ˈkætθθ", want: []string{"ˈkæt", "θθ"}},
+		{
+			name:        "frequent words win",
+			run:         "əneɪm",
+			frequencies: map[string]int{"a": 1000, "name": 200, "an": 300, "aim": 5},
+			want:        []string{"ə", "neɪm"},
+		},
+		{
+			name:        "frequencies can flip the split",
+			run:         "əneɪm",
+			frequencies: map[string]int{"a": 5, "name": 5, "an": 1000, "aim": 800},
+			want:        []string{"ən", "eɪm"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			indexes := phoneticIndexes{buildPhoneticIndex(pronunciations, test.frequencies)}
+			if got := indexes.segmentPhonemeRun(test.run); !slices.Equal(got, test.want) {
+				t.Errorf("%s segments as %q, want %q", test.run, got, test.want)
+			}
+		})
+	}
+}
+
+func TestSegmentCost(t *testing.T) {
+	if got := segmentCost(0, 0, 0); got != segmentWordCost {
+		t.Errorf("without frequencies a word costs %v, want %v", got, segmentWordCost)
+	}
+	if got := segmentCost(1, 0, 0); got != segmentWordCost+segmentVariantCost {
+		t.Errorf("a second pronunciation costs %v", got)
+	}
+	common, rare, unseen := segmentCost(0, 1000, 1000), segmentCost(0, 10, 1000), segmentCost(0, 0, 1000)
+	if common != segmentWordCost || !(common < rare && rare < unseen) || unseen != segmentWordCost+segmentRarityCost {
+		t.Errorf("costs are %v, %v and %v for common, rare and unseen words", common, rare, unseen)
+	}
+}
+
+func TestLoadWordFrequencies(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]int
+		err     string
+	}{
+		{name: "counts", content: "the\t500\nCat\t20\r\n\ncat\t5\n", want: map[string]int{"the": 500, "cat": 25}},
+		{name: "missing count", content: "the\t500\ncat\n", err: ":2: expected word<TAB>count"},
+		{name: "bad count", content: "the\tmany\n", err: ":1: count \"many\""},
+		{name: "zero count", content: "the\t0\n", err: ":1: count \"0\""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(dir, strings.ReplaceAll(test.name, " ", "_")+".freq")
+			if err := os.WriteFile(path, []byte(test.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := loadWordFrequencies(path)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Errorf("got error %v, want %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+			for word, count := range test.want {
+				if got[word] != count {
+					t.Errorf("%s has count %d, want %d", word, got[word], count)
+				}
+			}
+		})
+	}
+}
+
+func TestGneepSegmentsUnspacedGlyphs(t *testing.T) {
+	previous := currentPhonetics.Load()
+	storePhoneticIndex(testLanguage(t))
+	t.Cleanup(func() { currentPhonetics.Store(previous) })
+
+	a := activeAlphabet()
+	symbols := a.encode.translate("ðəˈkætˈsæt")
+	if strings.Contains(symbols, "☂") {
+		t.Fatalf("test glyphs %q contain a space", symbols)
+	}
+	if got := translateRecognizedSymbols(symbols); got != "ðə ˈkæt ˈsæt" {
+		t.Errorf("%s is read as %q", symbols, got)
+	}
+	if got, want := gneepMessage(symbols), "`ðə ˈkæt ˈsæt`\nthe cat sat"; got != want {
+		t.Errorf("gneep replies %q, want %q", got, want)
+	}
+}
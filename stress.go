@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	stressFull        = "full"
+	stressPrimary     = "primary"
+	stressNone        = "none"
+	stressWordInitial = "word-initial"
+)
+
+const defaultStressMode = stressFull
+
+const (
+	primaryStress   = 'ˈ'
+	secondaryStress = 'ˌ'
+)
+
+type syllable struct {
+	stress rune
+	sounds string
+}
+
+func parseStressMode(mode string) (string, error) {
+	switch mode {
+	case "":
+		return defaultStressMode, nil
+	case stressFull, stressPrimary, stressNone, stressWordInitial:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown stress mode: %s", mode)
+	}
+}
+
+func parseSyllables(ipa string) []syllable {
+	syllables := []syllable{}
+	current := syllable{}
+	for _, r := range ipa {
+		if r == primaryStress || r == secondaryStress {
+			if current.stress != 0 || current.sounds != "" {
+				syllables = append(syllables, current)
+			}
+			current = syllable{stress: r}
+			continue
+		}
+		current.sounds += string(r)
+	}
+	if current.stress != 0 || current.sounds != "" {
+		syllables = append(syllables, current)
+	}
+	return syllables
+}
+
+func applyStressMode(ipa string, mode string) string {
+	if mode == stressFull {
+		return ipa
+	}
+	syllables := parseSyllables(ipa)
+	var out strings.Builder
+	stressed := false
+	for _, s := range syllables {
+		stressed = stressed || s.stress != 0
+	}
+	if mode == stressWordInitial && stressed {
+		out.WriteRune(primaryStress)
+	}
+	for _, s := range syllables {
+		if s.stress == primaryStress && mode == stressPrimary {
+			out.WriteRune(s.stress)
+		}
+		out.WriteString(s.sounds)
+	}
+	return out.String()
+}
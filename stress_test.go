@@ -0,0 +1,61 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseSyllables(t *testing.T) {
+	tests := []struct {
+		ipa  string
+		want []syllable
+	}{
+		{ipa: "", want: []syllable{}},
+		{ipa: "ðə", want: []syllable{{sounds: "ðə"}}},
+		{ipa: "ˈkæt", want: []syllable{{stress: primaryStress, sounds: "kæt"}}},
+		{ipa: "ɹəˈkɔɹd", want: []syllable{{sounds: "ɹə"}, {stress: primaryStress, sounds: "kɔɹd"}}},
+		{ipa: "ˌɪnfɝˈmeɪʃən", want: []syllable{{stress: secondaryStress, sounds: "ɪnfɝ"}, {stress: primaryStress, sounds: "meɪʃən"}}},
+		{ipa: "ˈˌ", want: []syllable{{stress: primaryStress}, {stress: secondaryStress}}},
+	}
+	for _, test := range tests {
+		if got := parseSyllables(test.ipa); !slices.Equal(got, test.want) {
+			t.Errorf("parseSyllables(%q) = %q, want %q", test.ipa, got, test.want)
+		}
+	}
+}
+
+func TestApplyStressMode(t *testing.T) {
+	tests := []struct {
+		ipa  string
+		mode string
+		want string
+	}{
+		{ipa: "ˌɪnfɝˈmeɪʃən", mode: stressFull, want: "ˌɪnfɝˈmeɪʃən"},
+		{ipa: "ˌɪnfɝˈmeɪʃən", mode: stressPrimary, want: "ɪnfɝˈmeɪʃən"},
+		{ipa: "ˌɪnfɝˈmeɪʃən", mode: stressNone, want: "ɪnfɝmeɪʃən"},
+		{ipa: "ˌɪnfɝˈmeɪʃən", mode: stressWordInitial, want: "ˈɪnfɝmeɪʃən"},
+		{ipa: "ɹəˈkɔɹd", mode: stressWordInitial, want: "ˈɹəkɔɹd"},
+		{ipa: "ˌɔɫ", mode: stressFull, want: "ˌɔɫ"},
+		{ipa: "ˌɔɫ", mode: stressPrimary, want: "ɔɫ"},
+		{ipa: "ˌɔɫ", mode: stressNone, want: "ɔɫ"},
+		{ipa: "ˌɔɫ", mode: stressWordInitial, want: "ˈɔɫ"},
+		{ipa: "ðə", mode: stressFull, want: "ðə"},
+		{ipa: "ðə", mode: stressPrimary, want: "ðə"},
+		{ipa: "ðə", mode: stressNone, want: "ðə"},
+		{ipa: "ðə", mode: stressWordInitial, want: "ðə"},
+	}
+	for _, test := range tests {
+		if got := applyStressMode(test.ipa, test.mode); got != test.want {
+			t.Errorf("applyStressMode(%q, %s) = %q, want %q", test.ipa, test.mode, got, test.want)
+		}
+	}
+}
+
+func TestParseStressMode(t *testing.T) {
+	if mode, err := parseStressMode(""); err != nil || mode != defaultStressMode {
+		t.Errorf("empty mode parses as %q, %v", mode, err)
+	}
+	if _, err := parseStressMode("secondary"); err == nil {
+		t.Errorf("unknown mode parsed")
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"container/list"
+	"fmt"
+	"image"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"gocv.io/x/gocv"
+)
+
+const templateCacheBytes = 32 << 20
+const templateScaleStep = 1.01
+
+type glyphTemplate struct {
+	symbol string
+	mat    gocv.Mat
+	ink    image.Rectangle
+}
+
+type scaledKey struct {
+	template *glyphTemplate
+	level    int
+}
+
+type scaledTemplate struct {
+	key     scaledKey
+	mat     gocv.Mat
+	bytes   int
+	refs    int
+	evicted bool
+	element *list.Element
+}
+
+type templateBank struct {
+	lifetime  sync.RWMutex
+	closed    bool
+	templates []*glyphTemplate
+
+	mu     sync.Mutex
+	scaled map[scaledKey]*scaledTemplate
+	recent *list.List
+	bytes  int
+}
+
+var currentTemplates atomic.Pointer[templateBank]
+
+func loadTemplateBank(templates map[string]string) (*templateBank, error) {
+	bank := &templateBank{scaled: map[scaledKey]*scaledTemplate{}, recent: list.New()}
+	symbols := slices.SortedFunc(maps.Keys(templates), func(i, j string) int {
+		return strings.Compare(templates[i], templates[j])
+	})
+	for _, symbol := range symbols {
+		mat := gocv.IMRead(templates[symbol], gocv.IMReadGrayScale)
+		if mat.Empty() {
+			mat.Close()
+			bank.close()
+			return nil, fmt.Errorf("glyph %s: cannot read template %s", symbol, templates[symbol])
+		}
+		bank.templates = append(bank.templates, &glyphTemplate{symbol: symbol, mat: mat, ink: inkBounds(mat)})
+	}
+	if len(bank.templates) == 0 {
+		return nil, fmt.Errorf("no template files found")
+	}
+	return bank, nil
+}
+
+// Templates are padded to a common line height, so short glyphs sit in blank space.
+func inkBounds(mat gocv.Mat) image.Rectangle {
+	bounds := image.Rectangle{}
+	for y := range mat.Rows() {
+		for x := range mat.Cols() {
+			if mat.GetUCharAt(y, x) < 128 {
+				bounds = bounds.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	if bounds.Empty() {
+		return image.Rect(0, 0, mat.Cols(), mat.Rows())
+	}
+	return bounds
+}
+
+func initTemplateBank() error {
+	bank, err := loadTemplateBank(activeAlphabet().templates)
+	if err != nil {
+		return err
+	}
+	storeTemplateBank(bank)
+	return nil
+}
+
+func storeTemplateBank(bank *templateBank) {
+	if previous := currentTemplates.Swap(bank); previous != nil {
+		go previous.close()
+	}
+}
+
+func acquireTemplates() (*templateBank, error) {
+	for {
+		bank := currentTemplates.Load()
+		if bank == nil {
+			return nil, fmt.Errorf("templates are not loaded")
+		}
+		bank.lifetime.RLock()
+		if !bank.closed {
+			return bank, nil
+		}
+		bank.lifetime.RUnlock()
+	}
+}
+
+func (b *templateBank) release() {
+	b.lifetime.RUnlock()
+}
+
+func (b *templateBank) close() {
+	b.lifetime.Lock()
+	defer b.lifetime.Unlock()
+	b.closed = true
+	for _, tmpl := range b.templates {
+		tmpl.mat.Close()
+	}
+	for _, scaled := range b.scaled {
+		scaled.mat.Close()
+	}
+}
+
+func scaleLevel(scale float64) int {
+	return int(math.Round(math.Log(scale) / math.Log(templateScaleStep)))
+}
+
+func (t *glyphTemplate) size(scale float64) image.Point {
+	scale = math.Pow(templateScaleStep, float64(scaleLevel(scale)))
+	return image.Pt(int(float64(t.mat.Cols())*scale), int(float64(t.mat.Rows())*scale))
+}
+
+func (b *templateBank) resized(t *glyphTemplate, scale float64) (gocv.Mat, func()) {
+	key := scaledKey{template: t, level: scaleLevel(scale)}
+	b.mu.Lock()
+	scaled, exists := b.scaled[key]
+	if !exists {
+		b.mu.Unlock()
+		mat := gocv.NewMat()
+		size := t.size(scale)
+		gocv.Resize(t.mat, &mat, size, 0, 0, gocv.InterpolationLinear)
+		b.mu.Lock()
+		if scaled, exists = b.scaled[key]; exists {
+			mat.Close()
+		} else {
+			scaled = &scaledTemplate{key: key, mat: mat, bytes: size.X * size.Y}
+			scaled.element = b.recent.PushFront(scaled)
+			b.scaled[key] = scaled
+			b.bytes += scaled.bytes
+		}
+	}
+	b.recent.MoveToFront(scaled.element)
+	scaled.refs++
+	b.evict()
+	b.mu.Unlock()
+
+	// evict leaves Mats still in use open, so the last release closes them.
+	return scaled.mat, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		scaled.refs--
+		if scaled.evicted && scaled.refs == 0 {
+			scaled.mat.Close()
+		}
+	}
+}
+
+func (b *templateBank) evict() {
+	for b.bytes > templateCacheBytes && b.recent.Len() > 1 {
+		scaled := b.recent.Remove(b.recent.Back()).(*scaledTemplate)
+		delete(b.scaled, scaled.key)
+		b.bytes -= scaled.bytes
+		scaled.evicted = true
+		if scaled.refs == 0 {
+			scaled.mat.Close()
+		}
+	}
+}
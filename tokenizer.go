@@ -0,0 +1,66 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+var tokenPattern = regexp.MustCompile(`[\p{L}\p{M}\p{N}]+(?:['’][\p{L}\p{M}\p{N}]+)*['’]?|['’][\p{L}\p{M}\p{N}]+(?:['’][\p{L}\p{M}\p{N}]+)*['’]?|[^\s\p{L}\p{M}\p{N}]+|\s+`)
+
+var accentStripper = transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+
+func canonicalWord(word string) string {
+	return strings.ReplaceAll(strings.ToLower(norm.NFC.String(word)), "’", "'")
+}
+
+func stripAccents(word string) string {
+	stripped, _, err := transform.String(accentStripper, word)
+	if err != nil {
+		return word
+	}
+	return stripped
+}
+
+func isWordRune(r rune) bool {
+	return unicode.In(r, unicode.L, unicode.M, unicode.N)
+}
+
+func isApostrophe(r rune) bool {
+	return r == '\'' || r == '’'
+}
+
+func tokenizeWords(text string, known func(word string) bool, elisions map[string]string) [][]int {
+	tokens := [][]int{}
+	for _, bounds := range tokenPattern.FindAllStringIndex(text, -1) {
+		tokens = append(tokens, splitWord(text, bounds[0], bounds[1], known, elisions)...)
+	}
+	return tokens
+}
+
+func splitWord(text string, start int, end int, known func(word string) bool, elisions map[string]string) [][]int {
+	word := text[start:end]
+	apostrophe := strings.IndexFunc(word, isApostrophe)
+	if apostrophe < 0 || known(canonicalWord(word)) {
+		return [][]int{{start, end}}
+	}
+
+	if first, width := utf8.DecodeRuneInString(word); isApostrophe(first) && width < len(word) {
+		return append([][]int{{start, start + width}}, splitWord(text, start+width, end, known, elisions)...)
+	}
+	if last, width := utf8.DecodeLastRuneInString(word); isApostrophe(last) && width < len(word) {
+		return append(splitWord(text, start, end-width, known, elisions), []int{end - width, end})
+	}
+
+	_, width := utf8.DecodeRuneInString(word[apostrophe:])
+	split := start + apostrophe + width
+	if _, exists := elisions[canonicalWord(text[start:split])]; exists && split < end {
+		return append([][]int{{start, split}}, splitWord(text, split, end, known, elisions)...)
+	}
+	return [][]int{{start, end}}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+func tokenTexts(text string, tokens [][]int) []string {
+	texts := []string{}
+	for _, token := range tokens {
+		texts = append(texts, text[token[0]:token[1]])
+	}
+	return texts
+}
+
+func TestTokenizeWords(t *testing.T) {
+	known := map[string]bool{"don't": true, "aujourd'hui": true, "hello": true}
+	tests := []struct {
+		name     string
+		text     string
+		elisions map[string]string
+		want     []string
+	}{
+		{name: "nfc", text: norm.NFC.String("café noir"), want: []string{norm.NFC.String("café"), " ", "noir"}},
+		{name: "nfd keeps combining marks in the word", text: norm.NFD.String("café noir"), want: []string{norm.NFD.String("café"), " ", "noir"}},
+		{name: "straight apostrophe in a known word", text: "don't", want: []string{"don't"}},
+		{name: "curly apostrophe in a known word", text: "don’t", want: []string{"don’t"}},
+		{name: "elided article", text: "l'homme", elisions: languageElisions["fr_FR"], want: []string{"l'", "homme"}},
+		{name: "curly elided article", text: "l’homme", elisions: languageElisions["fr_FR"], want: []string{"l’", "homme"}},
+		{name: "elided conjunction", text: "jusqu'ici", elisions: languageElisions["fr_FR"], want: []string{"jusqu'", "ici"}},
+		{name: "known word with an apostrophe stays whole", text: "aujourd'hui", elisions: languageElisions["fr_FR"], want: []string{"aujourd'hui"}},
+		{name: "unknown word with an apostrophe stays whole", text: "aujourd’hui", elisions: map[string]string{}, want: []string{"aujourd’hui"}},
+		{name: "quotes stripped from word edges", text: "'hello'", want: []string{"'", "hello", "'"}},
+		{name: "curly quotes stripped from word edges", text: "‘hello’", want: []string{"‘", "hello", "’"}},
+		{name: "punctuation", text: "hello, world!", want: []string{"hello", ",", " ", "world", "!"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tokens := tokenizeWords(test.text, func(word string) bool { return known[word] }, test.elisions)
+			if got := tokenTexts(test.text, tokens); !slices.Equal(got, test.want) {
+				t.Errorf("tokenizeWords(%q) = %q, want %q", test.text, got, test.want)
+			}
+		})
+	}
+}
+
+func TestCanonicalWord(t *testing.T) {
+	if nfc, nfd := canonicalWord(norm.NFC.String("Café")), canonicalWord(norm.NFD.String("Café")); nfc != nfd || nfc != "café" {
+		t.Errorf("canonicalWord gives %q for nfc and %q for nfd", nfc, nfd)
+	}
+	if got := canonicalWord("Don’t"); got != "don't" {
+		t.Errorf("canonicalWord(%q) = %q", "Don’t", got)
+	}
+}
+
+func TestDictionaryWordsTokenizeAsOneWord(t *testing.T) {
+	if err := initAlphabet(); err != nil {
+		t.Fatal(err)
+	}
+	paths, err := filepath.Glob(filepath.Join(dictionaryDir, "*.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range paths {
+		code := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		t.Run(code, func(t *testing.T) {
+			language, _, err := loadLanguage(code, path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, word := range slices.Sorted(maps.Keys(maps.Collect(language.pronunciations.words()))) {
+				wordlike := strings.IndexFunc(word, isWordRune) >= 0 && !strings.ContainsFunc(word, func(r rune) bool {
+					return !isWordRune(r) && !isApostrophe(r)
+				})
+				if !wordlike {
+					continue
+				}
+				if tokens := language.Tokenize(word); len(tokens) != 1 || tokens[0][0] != 0 || tokens[0][1] != len(word) {
+					t.Errorf("%s splits into %q", word, tokenTexts(word, tokens))
+				}
+			}
+		})
+	}
+}
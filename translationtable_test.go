@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestParseDictionaryProblems(t *testing.T) {
+	if err := initAlphabet(); err != nil {
+		t.Fatal(err)
+	}
+	content := "cat\t/ˈkæt/\n" +
+		"\n" +
+		"dog\t/ˈdɔɡ/\n" +
+		"Cat\t/ˈkat/\n" +
+		"fish\t/ˈfɪʃ/, /ˈfɪ☃/\n" +
+		"bird\t//\n" +
+		"cow\t\n" +
+		"yak\tˈjæk\n" +
+		"\t/ˈæ/\n" +
+		"emu\t/ˈimju/\tbird\n"
+	path := filepath.Join(t.TempDir(), "en_US.txt")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	pronunciations, problems, err := parseDictionary(path, glyphCoverage(compileTransliterator()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []dictionaryProblem{
+		{path: path, line: 4, message: `duplicate headword "cat" (first defined on line 1)`},
+		{path: path, line: 5, message: `pronunciation /ˈfɪ☃/ of "fish" has no glyph for ☃`},
+		{path: path, line: 6, message: `empty pronunciation of "bird"`},
+		{path: path, line: 7, message: `empty pronunciation of "cow"`},
+		{path: path, line: 8, message: `pronunciation "ˈjæk" of "yak" is not wrapped in slashes`},
+		{path: path, line: 9, message: "missing headword"},
+		{path: path, line: 10, message: "expected word<TAB>pronunciations, found 3 fields"},
+	}
+	if !slices.Equal(problems, want) {
+		t.Errorf("got problems:\n%v\nwant:\n%v", problems, want)
+	}
+	if got := pronunciations["cat"]; !slices.Equal(got, []string{"ˈkæt"}) {
+		t.Errorf("cat is %q, want the first definition", got)
+	}
+	if _, exists := pronunciations["bird"]; exists {
+		t.Errorf("bird has pronunciations %q", pronunciations["bird"])
+	}
+	if got := problems[0].String(); got != path+`:4: duplicate headword "cat" (first defined on line 1)` {
+		t.Errorf("problem prints as %q", got)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+type translitRule struct {
+	from     string
+	to       string
+	priority int
+}
+
+type trieNode struct {
+	children map[rune]*trieNode
+	output   string
+	priority int
+	terminal bool
+}
+
+type transliterator struct {
+	root *trieNode
+}
+
+func rulesFromMap(mapping map[string]string) []translitRule {
+	rules := make([]translitRule, 0, len(mapping))
+	for from, to := range mapping {
+		rules = append(rules, translitRule{from: from, to: to})
+	}
+	slices.SortFunc(rules, func(i, j translitRule) int {
+		return strings.Compare(i.from, j.from)
+	})
+	return rules
+}
+
+func compileTransliterator(rules ...[]translitRule) *transliterator {
+	root := &trieNode{children: map[rune]*trieNode{}}
+	for _, ruleset := range rules {
+		for _, rule := range ruleset {
+			if rule.from == "" {
+				continue
+			}
+			node := root
+			for _, r := range rule.from {
+				child, exists := node.children[r]
+				if !exists {
+					child = &trieNode{children: map[rune]*trieNode{}}
+					node.children[r] = child
+				}
+				node = child
+			}
+			if !node.terminal || rule.priority > node.priority {
+				node.terminal = true
+				node.output = rule.to
+				node.priority = rule.priority
+			}
+		}
+	}
+	return &transliterator{root: root}
+}
+
+func (t *transliterator) match(s string) (string, int, bool) {
+	node := t.root
+	output, size, priority, found := "", 0, 0, false
+	for offset, r := range s {
+		child, exists := node.children[r]
+		if !exists {
+			break
+		}
+		node = child
+		if node.terminal && (!found || node.priority >= priority) {
+			output, size, priority, found = node.output, offset+utf8.RuneLen(r), node.priority, true
+		}
+	}
+	return output, size, found
+}
+
+func (t *transliterator) translate(s string) string {
+	var out strings.Builder
+	for len(s) > 0 {
+		if output, size, ok := t.match(s); ok {
+			out.WriteString(output)
+			s = s[size:]
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(s)
+		out.WriteString(s[:size])
+		s = s[size:]
+	}
+	return out.String()
+}
+
+func (a *alphabet) verifyRoundTrips(samples []string) []string {
+	failures := []string{}
+	for _, sample := range samples {
+		normalized := a.normalize.translate(sample)
+		encoded := a.encode.translate(normalized)
+		if decoded := a.decode.translate(encoded); decoded != normalized {
+			failures = append(failures, sample)
+			continue
+		}
+		if reencoded := a.encode.translate(a.decode.translate(encoded)); reencoded != encoded {
+			failures = append(failures, sample)
+		}
+	}
+	return failures
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"encoding/json"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func testAlphabet(t testing.TB) *alphabet {
+	t.Helper()
+	a, err := loadAlphabet(alphabetPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func alphabetSounds(a *alphabet) []string {
+	sounds := []string{}
+	for _, rule := range rulesFromMap(a.lookup) {
+		sounds = append(sounds, rule.to)
+	}
+	return sounds
+}
+
+func randomSoundStrings(sounds []string, count int) []string {
+	random := rand.New(rand.NewPCG(1, 2))
+	samples := []string{}
+	for range count {
+		var sample strings.Builder
+		for range 1 + random.IntN(12) {
+			sample.WriteString(sounds[random.IntN(len(sounds))])
+		}
+		samples = append(samples, sample.String())
+	}
+	return samples
+}
+
+func roundTripSamples(t *testing.T, a *alphabet) []string {
+	t.Helper()
+	pronunciations, err := loadDictionary("ipa/en_US.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	samples := randomSoundStrings(alphabetSounds(a), 5000)
+	for _, options := range pronunciations {
+		samples = append(samples, options...)
+	}
+	return samples
+}
+
+func TestEncodeDecodeRoundTrips(t *testing.T) {
+	a := testAlphabet(t)
+	for _, sample := range roundTripSamples(t, a) {
+		normalized := a.normalize.translate(sample)
+		if decoded := a.decode.translate(a.encode.translate(normalized)); decoded != normalized {
+			t.Errorf("%q decodes as %q", normalized, decoded)
+		}
+	}
+}
+
+func TestReencodingIsIdempotent(t *testing.T) {
+	a := testAlphabet(t)
+	for _, sample := range roundTripSamples(t, a) {
+		encoded := a.encode.translate(a.normalize.translate(sample))
+		if reencoded := a.encode.translate(a.decode.translate(encoded)); reencoded != encoded {
+			t.Errorf("%q encodes as %q, then as %q", sample, encoded, reencoded)
+		}
+	}
+}
+
+func TestCompiledOutputIsStable(t *testing.T) {
+	first := testAlphabet(t)
+	samples := randomSoundStrings(alphabetSounds(first), 2000)
+	for range 10 {
+		a := testAlphabet(t)
+		for _, sample := range samples {
+			if got, want := a.encode.translate(sample), first.encode.translate(sample); got != want {
+				t.Fatalf("%q encodes as %q and %q across compiles", sample, want, got)
+			}
+			if got, want := a.decode.translate(sample), first.decode.translate(sample); got != want {
+				t.Fatalf("%q decodes as %q and %q across compiles", sample, want, got)
+			}
+		}
+	}
+}
+
+func TestRulesFromMapIsSorted(t *testing.T) {
+	rules := rulesFromMap(map[string]string{"ʃ": "S", "t": "T", "tʃ": "C", "a": "A"})
+	if !slices.IsSortedFunc(rules, func(i, j translitRule) int { return strings.Compare(i.from, j.from) }) {
+		t.Errorf("rules are not sorted: %v", rules)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules [][]translitRule
+		input string
+		want  string
+		size  int
+	}{
+		{
+			name:  "longest match wins",
+			rules: [][]translitRule{{{from: "t", to: "T"}, {from: "ʃ", to: "S"}, {from: "tʃ", to: "C"}}},
+			input: "tʃa",
+			want:  "C",
+			size:  len("tʃ"),
+		},
+		{
+			name:  "shorter rule when the longer one doesn't continue",
+			rules: [][]translitRule{{{from: "t", to: "T"}, {from: "tʃ", to: "C"}}},
+			input: "ta",
+			want:  "T",
+			size:  len("t"),
+		},
+		{
+			name:  "first rule wins a tie",
+			rules: [][]translitRule{{{from: "ks", to: "X"}}, {{from: "ks", to: "K"}}},
+			input: "ks",
+			want:  "X",
+			size:  len("ks"),
+		},
+		{
+			name:  "priority overrides an earlier rule",
+			rules: [][]translitRule{{{from: "ks", to: "X"}}, {{from: "ks", to: "K", priority: 1}}},
+			input: "ks",
+			want:  "K",
+			size:  len("ks"),
+		},
+		{
+			name:  "sequence with a higher priority beats its components",
+			rules: [][]translitRule{{{from: "aɪ", to: "Y", priority: 2}}, {{from: "a", to: "A", priority: 1}, {from: "ɪ", to: "I"}}},
+			input: "aɪ",
+			want:  "Y",
+			size:  len("aɪ"),
+		},
+		{
+			name:  "priority beats a longer match",
+			rules: [][]translitRule{{{from: "a", to: "A", priority: 1}, {from: "aɪ", to: "I"}}},
+			input: "aɪ",
+			want:  "A",
+			size:  len("a"),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, size, ok := compileTransliterator(test.rules...).match(test.input)
+			if !ok || output != test.want || size != test.size {
+				t.Errorf("match(%q) = %q, %d, %v, want %q, %d", test.input, output, size, ok, test.want, test.size)
+			}
+		})
+	}
+}
+
+func TestTranslateKeepsUnknownRunes(t *testing.T) {
+	tr := compileTransliterator([]translitRule{{from: "a", to: "A"}})
+	if got := tr.translate("bab"); got != "bAb" {
+		t.Errorf("translate(%q) = %q", "bab", got)
+	}
+}
+
+func TestTranslateTAndShAgainstTsh(t *testing.T) {
+	a := testAlphabet(t)
+	t.Run("tʃ is one glyph", func(t *testing.T) {
+		if got := a.encode.translate("tʃ"); got != "☨" {
+			t.Errorf("tʃ encodes as %q", got)
+		}
+	})
+	t.Run("t then ʃ glyphs decode to tʃ", func(t *testing.T) {
+		if got := a.decode.translate("☞☝"); got != "tʃ" {
+			t.Errorf("☞☝ decodes as %q", got)
+		}
+	})
+	t.Run("ʧ is normalized to tʃ", func(t *testing.T) {
+		if got := a.encode.translate(a.normalize.translate("ʧ")); got != "☨" {
+			t.Errorf("ʧ encodes as %q", got)
+		}
+	})
+}
+
+func TestAlphabetSequenceOverride(t *testing.T) {
+	var file map[string]any
+	filedata, err := os.ReadFile(alphabetPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(filedata, &file); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "alphabet.json")
+	writeSequences := func(sequences ...map[string]any) {
+		file["sequences"] = sequences
+		filedata, err := json.Marshal(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, filedata, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeSequences(map[string]any{"ipa": "tʃ", "glyphs": "☞☝"})
+	if _, err := loadAlphabet(path); err == nil || !strings.Contains(err.Error(), "give it a priority") {
+		t.Errorf("sequence without a priority loaded with %v", err)
+	}
+
+	writeSequences(map[string]any{"ipa": "tʃ", "glyphs": "☞☝", "priority": 1})
+	a, err := loadAlphabet(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := a.encode.translate("ˈtʃɪp"); got != "☁☞☝☒☚" {
+		t.Errorf("ˈtʃɪp encodes as %q", got)
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	unmappedKeep    = "keep"
+	unmappedDrop    = "drop"
+	unmappedNearest = "nearest"
+)
+
+const defaultUnmappedPolicy = unmappedKeep
+
+type UnmappedSegment struct {
+	Segment    string `json:"segment"`
+	Word       string `json:"word"`
+	WordOffset int    `json:"wordOffset"`
+	Offset     int    `json:"offset"`
+}
+
+func parseUnmappedPolicy(policy string) (string, error) {
+	switch policy {
+	case "":
+		return defaultUnmappedPolicy, nil
+	case unmappedKeep, unmappedDrop, unmappedNearest:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown unmapped policy: %s", policy)
+	}
+}
+
+func substituteUnmapped(segment string, policy string) string {
+	switch policy {
+	case unmappedDrop:
+		return ""
+	case unmappedNearest:
+		a := activeAlphabet()
+		var substituted strings.Builder
+		for _, r := range segment {
+			nearest := a.nearest[string(r)]
+			for len(nearest) > 0 {
+				glyph, size, ok := a.encode.match(nearest)
+				if !ok {
+					_, size = utf8.DecodeRuneInString(nearest)
+				}
+				substituted.WriteString(glyph)
+				nearest = nearest[size:]
+			}
+		}
+		return substituted.String()
+	default:
+		return segment
+	}
+}
+
+var unmappedOutcomes = map[string]string{
+	unmappedKeep:    "kept as-is",
+	unmappedDrop:    "dropped",
+	unmappedNearest: "replaced with the nearest glyph",
+}
+
+func unmappedNote(unmapped []UnmappedSegment, policy string) string {
+	if len(unmapped) == 0 {
+		return ""
+	}
+	segments := []string{}
+	for _, segment := range unmapped {
+		note := fmt.Sprintf("`%s`", segment.Segment)
+		if segment.Word != segment.Segment {
+			note += fmt.Sprintf(" (%s)", segment.Word)
+		}
+		if !slices.Contains(segments, note) {
+			segments = append(segments, note)
+		}
+	}
+	return fmt.Sprintf("\n-# no glyph for %s, %s", strings.Join(segments, ", "), unmappedOutcomes[policy])
+}
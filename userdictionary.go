@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const userDictionaryPath = "userdict.json"
+const adminTokenEnv = "GNARP_ADMIN_TOKEN"
+
+type UserWord struct {
+	Word string `json:"word"`
+	IPA  string `json:"ipa"`
+	Lang string `json:"lang"`
+}
+
+type userDictionary struct {
+	mu        sync.RWMutex
+	path      string
+	words     map[string]map[string]string
+	phonetics atomic.Pointer[phoneticDictionary]
+}
+
+var userWords = &userDictionary{path: userDictionaryPath, words: map[string]map[string]string{}}
+
+func loadUserDictionary(path string) (*userDictionary, error) {
+	dictionary := &userDictionary{path: path, words: map[string]map[string]string{}}
+	filedata, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return dictionary, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	entries := []UserWord{}
+	if err := json.Unmarshal(filedata, &entries); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	for _, entry := range entries {
+		if dictionary.words[entry.Lang] == nil {
+			dictionary.words[entry.Lang] = map[string]string{}
+		}
+		dictionary.words[entry.Lang][entry.Word] = entry.IPA
+	}
+	dictionary.indexPhonetics()
+	return dictionary, nil
+}
+
+func (d *userDictionary) indexPhonetics() {
+	pronunciations := mapTable{}
+	for word, ipa := range d.words[defaultLanguage] {
+		pronunciations[word] = []string{ipa}
+	}
+	d.phonetics.Store(buildPhoneticIndex(pronunciations, nil))
+}
+
+func (d *userDictionary) reindex() {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	d.indexPhonetics()
+}
+
+func (d *userDictionary) lookup(lang string, word string) (string, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	ipa, exists := d.words[lang][word]
+	return ipa, exists
+}
+
+func (d *userDictionary) entries() []UserWord {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.sortedEntries()
+}
+
+func (d *userDictionary) sortedEntries() []UserWord {
+	entries := []UserWord{}
+	for lang, words := range d.words {
+		for word, ipa := range words {
+			entries = append(entries, UserWord{Word: word, IPA: ipa, Lang: lang})
+		}
+	}
+	slices.SortFunc(entries, func(i, j UserWord) int {
+		if i.Lang != j.Lang {
+			return strings.Compare(i.Lang, j.Lang)
+		}
+		return strings.Compare(i.Word, j.Word)
+	})
+	return entries
+}
+
+func normalizeUserWord(entry UserWord) (UserWord, error) {
+	language, err := languageFor(entry.Lang)
+	if err != nil {
+		return entry, err
+	}
+	entry.Lang = language.Code()
+	entry.Word = canonicalWord(strings.TrimSpace(entry.Word))
+	if strings.IndexFunc(entry.Word, isWordRune) < 0 || strings.ContainsFunc(entry.Word, func(r rune) bool {
+		return !isWordRune(r) && !isApostrophe(r)
+	}) {
+		return entry, fmt.Errorf("not a single word: %q", entry.Word)
+	}
+	entry.IPA = norm.NFC.String(strings.Trim(strings.TrimSpace(entry.IPA), "/"))
+	if entry.IPA == "" || strings.ContainsFunc(entry.IPA, func(r rune) bool { return r == ' ' || r == '\t' }) {
+		return entry, fmt.Errorf("pronunciation must be a single word of ipa: %q", entry.IPA)
+	}
+	a := activeAlphabet()
+	if !a.encodable(a.normalize.translate(language.Normalize(entry.IPA))) {
+		return entry, fmt.Errorf("pronunciation %q has sounds without a glyph", entry.IPA)
+	}
+	return entry, nil
+}
+
+func (d *userDictionary) set(entry UserWord) (UserWord, error) {
+	entry, err := normalizeUserWord(entry)
+	if err != nil {
+		return entry, err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.words[entry.Lang] == nil {
+		d.words[entry.Lang] = map[string]string{}
+	}
+	previous, existed := d.words[entry.Lang][entry.Word]
+	d.words[entry.Lang][entry.Word] = entry.IPA
+	if err := d.save(); err != nil {
+		if existed {
+			d.words[entry.Lang][entry.Word] = previous
+		} else {
+			delete(d.words[entry.Lang], entry.Word)
+		}
+		return entry, err
+	}
+	d.indexPhonetics()
+	return entry, nil
+}
+
+func (d *userDictionary) remove(lang string, word string) (bool, error) {
+	language, err := languageFor(lang)
+	if err != nil {
+		return false, err
+	}
+	lang, word = language.Code(), canonicalWord(word)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	previous, exists := d.words[lang][word]
+	if !exists {
+		return false, nil
+	}
+	delete(d.words[lang], word)
+	if err := d.save(); err != nil {
+		d.words[lang][word] = previous
+		return false, err
+	}
+	d.indexPhonetics()
+	return true, nil
+}
+
+func (d *userDictionary) save() error {
+	filedata, err := json.MarshalIndent(d.sortedEntries(), "", "  ")
+	if err != nil {
+		return err
+	}
+	temp, err := os.CreateTemp(filepath.Dir(d.path), filepath.Base(d.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+	if _, err := temp.Write(append(filedata, '\n')); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), d.path)
+}
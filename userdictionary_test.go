@@ -0,0 +1,67 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+var registerTestLanguages = sync.OnceValue(func() error {
+	if err := initAlphabet(); err != nil {
+		return err
+	}
+	return registerLanguages(dictionaryDir)
+})
+
+func testUserDictionary(t *testing.T) *userDictionary {
+	t.Helper()
+	if err := registerTestLanguages(); err != nil {
+		t.Fatal(err)
+	}
+	dictionary, err := loadUserDictionary(filepath.Join(t.TempDir(), userDictionaryPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := userWords
+	userWords = dictionary
+	t.Cleanup(func() { userWords = previous })
+	return dictionary
+}
+
+func TestLearnedWordsDecode(t *testing.T) {
+	dictionary := testUserDictionary(t)
+	if _, err := dictionary.set(UserWord{Word: "Zorblax", IPA: "ˈzɔɹblæks", Lang: defaultLanguage}); err != nil {
+		t.Fatal(err)
+	}
+
+	words := translateSoundsToEnglish("ˈzɔɹblæks", 3)
+	if len(words) != 1 || len(words[0].Candidates) == 0 || words[0].Candidates[0].Word != "zorblax" {
+		t.Fatalf("learned word does not decode: %+v", words)
+	}
+	if got := segmentSounds("ˈzɔɹblæks"); got != "ˈzɔɹblæks" {
+		t.Errorf("learned word segments as %q", got)
+	}
+
+	if _, err := dictionary.remove(defaultLanguage, "zorblax"); err != nil {
+		t.Fatal(err)
+	}
+	for _, candidate := range translateSoundsToEnglish("ˈzɔɹblæks", 3)[0].Candidates {
+		if candidate.Word == "zorblax" {
+			t.Errorf("removed word still decodes")
+		}
+	}
+}
+
+func TestDictionaryPreflight(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	Dictionary(recorder, httptest.NewRequest(http.MethodOptions, "/api/v1/dictionary", nil))
+	allowed := strings.ToLower(recorder.Header().Get("Access-Control-Allow-Headers"))
+	for _, header := range []string{"authorization", "content-type"} {
+		if !strings.Contains(allowed, header) {
+			t.Errorf("preflight does not allow the %s header: %q", header, allowed)
+		}
+	}
+}
@@ -0,0 +1,513 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const maxVerbalized = 999_999_999_999
+
+// Two-digit years below the pivot are read as 20xx, the rest as 19xx.
+const twoDigitYearPivot = 50
+
+type verbalSegment struct {
+	text     string
+	source   string
+	offset   int
+	expanded bool
+}
+
+type verbalRule struct {
+	pattern *regexp.Regexp
+	span    int // capture group that is replaced; the rest of the match is context
+	expand  func(v *verbalizer, groups []string) (string, bool)
+}
+
+type verbalizer struct {
+	cardinal      func(n int64) string
+	ordinal       func(n int64) string
+	year          func(n int64) string
+	time          func(hours int64, minutes int64, suffix string) string
+	date          func(first int64, second int64) (string, bool)
+	decimal       string
+	currencies    map[string][2]string
+	subunit       [2]string
+	percent       string
+	street        string
+	ordinalSuffix *regexp.Regexp
+	abbreviations map[string]string
+	rules         []verbalRule
+}
+
+var englishOnes = []string{"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine", "ten",
+	"eleven", "twelve", "thirteen", "fourteen", "fifteen", "sixteen", "seventeen", "eighteen", "nineteen"}
+var englishTens = []string{"", "", "twenty", "thirty", "forty", "fifty", "sixty", "seventy", "eighty", "ninety"}
+var englishScales = []string{"", "thousand", "million", "billion"}
+var englishOrdinals = map[string]string{
+	"one": "first", "two": "second", "three": "third", "five": "fifth",
+	"eight": "eighth", "nine": "ninth", "twelve": "twelfth",
+}
+var englishMonths = []string{"january", "february", "march", "april", "may", "june",
+	"july", "august", "september", "october", "november", "december"}
+
+var frenchOnes = []string{"zéro", "un", "deux", "trois", "quatre", "cinq", "six", "sept", "huit", "neuf", "dix",
+	"onze", "douze", "treize", "quatorze", "quinze", "seize"}
+var frenchTens = []string{"", "dix", "vingt", "trente", "quarante", "cinquante", "soixante", "soixante", "quatre vingt", "quatre vingt"}
+var frenchScales = []string{"", "mille", "million", "milliard"}
+var frenchMonths = []string{"janvier", "février", "mars", "avril", "mai", "juin",
+	"juillet", "août", "septembre", "octobre", "novembre", "décembre"}
+
+func englishCardinal(n int64) string {
+	if n < 20 {
+		return englishOnes[n]
+	}
+	if n < 100 {
+		if n%10 == 0 {
+			return englishTens[n/10]
+		}
+		return englishTens[n/10] + " " + englishOnes[n%10]
+	}
+	if n < 1000 {
+		if n%100 == 0 {
+			return englishOnes[n/100] + " hundred"
+		}
+		return englishOnes[n/100] + " hundred " + englishCardinal(n%100)
+	}
+	words := []string{}
+	for scale := len(englishScales) - 1; scale >= 0; scale-- {
+		unit := int64(1)
+		for range scale {
+			unit *= 1000
+		}
+		if chunk := n / unit % 1000; chunk > 0 {
+			words = append(words, strings.TrimSpace(englishCardinal(chunk)+" "+englishScales[scale]))
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+func englishOrdinal(n int64) string {
+	words := strings.Fields(englishCardinal(n))
+	last := words[len(words)-1]
+	switch {
+	case englishOrdinals[last] != "":
+		last = englishOrdinals[last]
+	case strings.HasSuffix(last, "y"):
+		last = strings.TrimSuffix(last, "y") + "ieth"
+	default:
+		last += "th"
+	}
+	words[len(words)-1] = last
+	return strings.Join(words, " ")
+}
+
+func englishYear(n int64) string {
+	if n < 1100 || n >= 10000 || (n >= 2000 && n < 2010) {
+		return englishCardinal(n)
+	}
+	if n%100 == 0 {
+		return englishCardinal(n/100) + " hundred"
+	}
+	if n%100 < 10 {
+		return englishCardinal(n/100) + " oh " + englishCardinal(n%100)
+	}
+	return englishCardinal(n/100) + " " + englishCardinal(n%100)
+}
+
+func englishTime(hours int64, minutes int64, suffix string) string {
+	words := englishCardinal(hours)
+	switch {
+	case minutes == 0 && suffix == "":
+		words += " o'clock"
+	case minutes == 0:
+	case minutes < 10:
+		words += " oh " + englishCardinal(minutes)
+	default:
+		words += " " + englishCardinal(minutes)
+	}
+	if suffix != "" {
+		words += " " + strings.Join(strings.Split(strings.ReplaceAll(strings.ToLower(suffix), ".", ""), ""), " ")
+	}
+	return words
+}
+
+func englishDate(month int64, day int64) (string, bool) {
+	if month < 1 || month > 12 || day < 1 || day > 31 {
+		return "", false
+	}
+	return englishMonths[month-1] + " " + englishOrdinal(day), true
+}
+
+func frenchCardinal(n int64) string {
+	if n <= 16 {
+		return frenchOnes[n]
+	}
+	if n < 100 {
+		tens, ones := n/10, n%10
+		if tens == 7 || tens == 9 {
+			ones += 10
+		}
+		switch {
+		case ones == 0 && tens == 8:
+			return "quatre vingts"
+		case ones == 0:
+			return frenchTens[tens]
+		case ones == 1 && tens != 8 && tens != 9:
+			return frenchTens[tens] + " et un"
+		case ones == 11 && tens == 7:
+			return frenchTens[tens] + " et onze"
+		case tens == 1:
+			return "dix " + frenchOnes[ones]
+		}
+		return frenchTens[tens] + " " + frenchCardinal(ones)
+	}
+	if n < 1000 {
+		hundreds := ""
+		if n/100 > 1 {
+			hundreds = frenchOnes[n/100] + " "
+		}
+		if n%100 == 0 {
+			if n/100 > 1 {
+				return hundreds + "cents"
+			}
+			return "cent"
+		}
+		return hundreds + "cent " + frenchCardinal(n%100)
+	}
+	words := []string{}
+	for scale := len(frenchScales) - 1; scale >= 0; scale-- {
+		unit := int64(1)
+		for range scale {
+			unit *= 1000
+		}
+		chunk := n / unit % 1000
+		switch {
+		case chunk == 0:
+		case scale == 0:
+			words = append(words, frenchCardinal(chunk))
+		case scale == 1 && chunk == 1:
+			words = append(words, "mille")
+		case scale == 1:
+			words = append(words, frenchCardinal(chunk)+" mille")
+		case chunk == 1:
+			words = append(words, "un "+frenchScales[scale])
+		default:
+			words = append(words, frenchCardinal(chunk)+" "+frenchScales[scale]+"s")
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+func frenchOrdinal(n int64) string {
+	if n == 1 {
+		return "premier"
+	}
+	words := strings.Fields(frenchCardinal(n))
+	last := strings.TrimSuffix(words[len(words)-1], "s")
+	switch last {
+	case "cinq":
+		last = "cinquième"
+	case "neuf":
+		last = "neuvième"
+	default:
+		last = strings.TrimSuffix(last, "e") + "ième"
+	}
+	words[len(words)-1] = last
+	return strings.Join(words, " ")
+}
+
+func frenchTime(hours int64, minutes int64, suffix string) string {
+	words := frenchCardinal(hours) + " heures"
+	if hours == 1 {
+		words = "une heure"
+	}
+	if minutes > 0 {
+		words += " " + frenchCardinal(minutes)
+	}
+	return words
+}
+
+func frenchDate(day int64, month int64) (string, bool) {
+	if month < 1 || month > 12 || day < 1 || day > 31 {
+		return "", false
+	}
+	if day == 1 {
+		return "premier " + frenchMonths[month-1], true
+	}
+	return frenchCardinal(day) + " " + frenchMonths[month-1], true
+}
+
+func parseVerbalNumber(digits string) (int64, bool) {
+	n, err := strconv.ParseInt(strings.ReplaceAll(digits, ",", ""), 10, 64)
+	if err != nil || n > maxVerbalized {
+		return 0, false
+	}
+	return n, true
+}
+
+func (v *verbalizer) number(digits string, fraction string) (string, bool) {
+	n, ok := parseVerbalNumber(digits)
+	if !ok {
+		return "", false
+	}
+	words := v.cardinal(n)
+	if fraction != "" {
+		digitWords := []string{}
+		for _, digit := range fraction {
+			digitWords = append(digitWords, v.cardinal(int64(digit-'0')))
+		}
+		words += " " + v.decimal + " " + strings.Join(digitWords, " ")
+	}
+	return words, true
+}
+
+var verbalRules = []verbalRule{
+	{
+		pattern: regexp.MustCompile(`(?i)\b(\d{1,2}):(\d{2})(?:\s?([ap]\.?m\b\.?))?`),
+		expand: func(v *verbalizer, groups []string) (string, bool) {
+			hours, _ := strconv.ParseInt(groups[1], 10, 64)
+			minutes, _ := strconv.ParseInt(groups[2], 10, 64)
+			if hours > 24 || minutes > 59 {
+				return "", false
+			}
+			return v.time(hours, minutes, groups[3]), true
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`(?i)\b(\d{1,2})\s?([ap]\.?m\b\.?)`),
+		expand: func(v *verbalizer, groups []string) (string, bool) {
+			hours, _ := strconv.ParseInt(groups[1], 10, 64)
+			if hours < 1 || hours > 12 {
+				return "", false
+			}
+			return v.time(hours, 0, groups[2]), true
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`\b(\d{1,2})/(\d{1,2})(?:/(\d{4}|\d{2}))?\b`),
+		expand: func(v *verbalizer, groups []string) (string, bool) {
+			first, _ := strconv.ParseInt(groups[1], 10, 64)
+			second, _ := strconv.ParseInt(groups[2], 10, 64)
+			date, ok := v.date(first, second)
+			if !ok {
+				return "", false
+			}
+			if groups[3] != "" {
+				year, _ := strconv.ParseInt(groups[3], 10, 64)
+				switch {
+				case len(groups[3]) != 2:
+				case year < twoDigitYearPivot:
+					year += 2000
+				default:
+					year += 1900
+				}
+				date += " " + v.year(year)
+			}
+			return date, true
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`([$€£])\s?(\d{1,3}(?:,\d{3})+|\d+)(?:[.,](\d{2}))?\b|\b(\d{1,3}(?:,\d{3})+|\d+)(?:[.,](\d{2}))?\s?([$€£])`),
+		expand: func(v *verbalizer, groups []string) (string, bool) {
+			symbol, digits, cents := groups[1], groups[2], groups[3]
+			if symbol == "" {
+				symbol, digits, cents = groups[6], groups[4], groups[5]
+			}
+			n, ok := parseVerbalNumber(digits)
+			if !ok {
+				return "", false
+			}
+			unit := v.currencies[symbol]
+			words := v.cardinal(n) + " " + plural(unit, n)
+			if cents != "" {
+				if c, _ := strconv.ParseInt(cents, 10, 64); c > 0 {
+					words += " " + v.cardinal(c) + " " + plural(v.subunit, c)
+				}
+			}
+			return words, true
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`\b\p{Lu}[\p{L}'’]*\s+(St\b\.?)(\s+\p{Lu})?`),
+		span:    1,
+		expand: func(v *verbalizer, groups []string) (string, bool) {
+			if groups[2] != "" {
+				return "", false
+			}
+			return v.street, v.street != ""
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`\b(\d+)(?:[.,](\d+))?\s?%`),
+		expand: func(v *verbalizer, groups []string) (string, bool) {
+			words, ok := v.number(groups[1], groups[2])
+			if !ok {
+				return "", false
+			}
+			return words + " " + v.percent, true
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`(?i)\b(\d+)(st|nd|rd|th|er|re|e|ème|eme)\b`),
+		expand: func(v *verbalizer, groups []string) (string, bool) {
+			if !v.ordinalSuffix.MatchString(groups[2]) {
+				return "", false
+			}
+			n, ok := parseVerbalNumber(groups[1])
+			if !ok {
+				return "", false
+			}
+			return v.ordinal(n), true
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`\b(\d{1,3}(?:,\d{3})+|\d+)(?:\.(\d+))?\b`),
+		expand: func(v *verbalizer, groups []string) (string, bool) {
+			return v.number(groups[1], groups[2])
+		},
+	},
+}
+
+var languageVerbalizers = map[string]*verbalizer{
+	"en_US": {
+		cardinal: englishCardinal,
+		ordinal:  englishOrdinal,
+		year:     englishYear,
+		time:     englishTime,
+		date:     englishDate,
+		decimal:  "point",
+		currencies: map[string][2]string{
+			"$": {"dollar", "dollars"},
+			"€": {"euro", "euros"},
+			"£": {"pound", "pounds"},
+		},
+		subunit:       [2]string{"cent", "cents"},
+		percent:       "percent",
+		street:        "street",
+		ordinalSuffix: regexp.MustCompile(`(?i)^(st|nd|rd|th)$`),
+		abbreviations: map[string]string{
+			"dr.":  "doctor",
+			"mr.":  "mister",
+			"mrs.": "missus",
+			"ms.":  "miz",
+			"st.":  "saint",
+			"vs.":  "versus",
+			"etc.": "et cetera",
+			"e.g.": "for example",
+			"i.e.": "that is",
+			"&":    "and",
+			"+":    "plus",
+			"=":    "equals",
+			"@":    "at",
+		},
+	},
+	"fr_FR": {
+		cardinal: frenchCardinal,
+		ordinal:  frenchOrdinal,
+		year:     frenchCardinal,
+		time:     frenchTime,
+		date:     frenchDate,
+		decimal:  "virgule",
+		currencies: map[string][2]string{
+			"$": {"dollar", "dollars"},
+			"€": {"euro", "euros"},
+			"£": {"livre", "livres"},
+		},
+		subunit:       [2]string{"centime", "centimes"},
+		percent:       "pour cent",
+		ordinalSuffix: regexp.MustCompile(`(?i)^(er|re|e|ème|eme)$`),
+		abbreviations: map[string]string{
+			"m.":   "monsieur",
+			"mme":  "madame",
+			"mlle": "mademoiselle",
+			"dr":   "docteur",
+			"etc.": "et cetera",
+			"&":    "et",
+			"+":    "plus",
+			"=":    "égale",
+			"@":    "arobase",
+		},
+	},
+}
+
+func plural(forms [2]string, n int64) string {
+	if n == 1 {
+		return forms[0]
+	}
+	return forms[1]
+}
+
+func (v *verbalizer) compile() {
+	abbreviations := slices.Collect(maps.Keys(v.abbreviations))
+	slices.SortFunc(abbreviations, func(i, j string) int {
+		if len(i) != len(j) {
+			return len(j) - len(i)
+		}
+		return strings.Compare(i, j)
+	})
+	alternatives := make([]string, len(abbreviations))
+	for i, abbreviation := range abbreviations {
+		alternative := regexp.QuoteMeta(abbreviation)
+		if isPlainWord(abbreviation[:1]) {
+			alternative = `\b` + alternative
+		}
+		if isPlainWord(abbreviation[len(abbreviation)-1:]) {
+			alternative += `\b`
+		}
+		alternatives[i] = alternative
+	}
+	v.rules = slices.Clone(verbalRules)
+	if len(alternatives) > 0 {
+		v.rules = append(v.rules, verbalRule{
+			pattern: regexp.MustCompile(fmt.Sprintf(`(?i)(%s)`, strings.Join(alternatives, "|"))),
+			expand: func(v *verbalizer, groups []string) (string, bool) {
+				words, exists := v.abbreviations[strings.ToLower(groups[1])]
+				return words, exists
+			},
+		})
+	}
+}
+
+func (v *verbalizer) verbalize(text string) []verbalSegment {
+	segments := []verbalSegment{{text: text, source: text}}
+	if v == nil {
+		return segments
+	}
+	for _, rule := range v.rules {
+		next := []verbalSegment{}
+		for _, segment := range segments {
+			if segment.expanded {
+				next = append(next, segment)
+				continue
+			}
+			plain := 0
+			for _, match := range rule.pattern.FindAllStringSubmatchIndex(segment.text, -1) {
+				groups := make([]string, len(match)/2)
+				for i := range groups {
+					if match[2*i] >= 0 {
+						groups[i] = segment.text[match[2*i]:match[2*i+1]]
+					}
+				}
+				words, ok := rule.expand(v, groups)
+				if !ok {
+					continue
+				}
+				start, end := match[2*rule.span], match[2*rule.span+1]
+				if plain < start {
+					next = append(next, verbalSegment{text: segment.text[plain:start], source: segment.text[plain:start], offset: segment.offset + plain})
+				}
+				next = append(next, verbalSegment{text: words, source: groups[rule.span], offset: segment.offset + start, expanded: true})
+				plain = end
+			}
+			if plain < len(segment.text) {
+				next = append(next, verbalSegment{text: segment.text[plain:], source: segment.text[plain:], offset: segment.offset + plain})
+			}
+		}
+		segments = next
+	}
+	return segments
+}
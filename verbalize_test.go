@@ -0,0 +1,95 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func verbalizedText(code string, text string) string {
+	v := languageVerbalizers[code]
+	v.compile()
+	var verbalized strings.Builder
+	for _, segment := range v.verbalize(text) {
+		verbalized.WriteString(segment.text)
+	}
+	return verbalized.String()
+}
+
+func TestVerbalize(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		text string
+		want string
+	}{
+		{name: "small cardinal", code: "en_US", text: "7", want: "seven"},
+		{name: "teen cardinal", code: "en_US", text: "13", want: "thirteen"},
+		{name: "hundreds", code: "en_US", text: "342", want: "three hundred forty two"},
+		{name: "grouped thousands", code: "en_US", text: "1,000,001", want: "one million one"},
+		{name: "decimal", code: "en_US", text: "3.14", want: "three point one four"},
+		{name: "too large to read", code: "en_US", text: "1000000000000", want: "1000000000000"},
+		{name: "ordinal", code: "en_US", text: "the 1st and 22nd", want: "the first and twenty second"},
+		{name: "ordinal in y", code: "en_US", text: "40th", want: "fortieth"},
+		{name: "mismatched ordinal suffix", code: "en_US", text: "3e", want: "3e"},
+		{name: "time on the hour", code: "en_US", text: "at 7:00", want: "at seven o'clock"},
+		{name: "time with minutes", code: "en_US", text: "9:05", want: "nine oh five"},
+		{name: "time with pm", code: "en_US", text: "10:30 pm", want: "ten thirty p m"},
+		{name: "hour with a.m.", code: "en_US", text: "6a.m.", want: "six a m"},
+		{name: "hour out of range", code: "en_US", text: "13pm", want: "13pm"},
+		{name: "invalid time", code: "en_US", text: "25:00", want: "twenty five:zero"},
+		{name: "date", code: "en_US", text: "7/4", want: "july fourth"},
+		{name: "date with year", code: "en_US", text: "12/25/1999", want: "december twenty fifth nineteen ninety nine"},
+		{name: "two-digit year after the pivot", code: "en_US", text: "1/2/99", want: "january second nineteen ninety nine"},
+		{name: "two-digit year before the pivot", code: "en_US", text: "1/2/07", want: "january second two thousand seven"},
+		{name: "invalid date", code: "en_US", text: "13/2", want: "thirteen/two"},
+		{name: "dollars and cents", code: "en_US", text: "$3.50", want: "three dollars fifty cents"},
+		{name: "one pound", code: "en_US", text: "£1", want: "one pound"},
+		{name: "trailing euro sign", code: "en_US", text: "20€", want: "twenty euros"},
+		{name: "percent", code: "en_US", text: "45%", want: "forty five percent"},
+		{name: "decimal percent", code: "en_US", text: "2.5%", want: "two point five percent"},
+		{name: "abbreviations", code: "en_US", text: "Dr. Who & co", want: "doctor Who and co"},
+		{name: "saint before a name", code: "en_US", text: "St. Louis", want: "saint Louis"},
+		{name: "saint after a capitalized word", code: "en_US", text: "Visit St. Louis", want: "Visit saint Louis"},
+		{name: "street after a name", code: "en_US", text: "on Main St. today", want: "on Main street today"},
+		{name: "street at the end", code: "en_US", text: "Baker St.", want: "Baker street"},
+
+		{name: "french cardinal", code: "fr_FR", text: "71", want: "soixante et onze"},
+		{name: "french eighty", code: "fr_FR", text: "80", want: "quatre vingts"},
+		{name: "french ninety one", code: "fr_FR", text: "91", want: "quatre vingt onze"},
+		{name: "french hundreds", code: "fr_FR", text: "200", want: "deux cents"},
+		{name: "french thousands", code: "fr_FR", text: "1001", want: "mille un"},
+		{name: "french millions", code: "fr_FR", text: "2,000,000", want: "deux millions"},
+		{name: "french decimal", code: "fr_FR", text: "3,5 %", want: "trois virgule cinq pour cent"},
+		{name: "french first", code: "fr_FR", text: "1er", want: "premier"},
+		{name: "french ordinal", code: "fr_FR", text: "21e", want: "vingt et unième"},
+		{name: "french fifth", code: "fr_FR", text: "5ème", want: "cinquième"},
+		{name: "french time", code: "fr_FR", text: "14:30", want: "quatorze heures trente"},
+		{name: "french one o'clock", code: "fr_FR", text: "1:00", want: "une heure"},
+		{name: "french date", code: "fr_FR", text: "14/7/1789", want: "quatorze juillet mille sept cent quatre vingt neuf"},
+		{name: "french first of the month", code: "fr_FR", text: "1/5", want: "premier mai"},
+		{name: "french euros", code: "fr_FR", text: "3,20 €", want: "trois euros vingt centimes"},
+		{name: "french street is not expanded", code: "fr_FR", text: "Rue St.", want: "Rue St."},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := verbalizedText(test.code, test.text); got != test.want {
+				t.Errorf("%s verbalizes %q as %q, want %q", test.code, test.text, got, test.want)
+			}
+		})
+	}
+}
+
+func TestVerbalizeKeepsSourceOffsets(t *testing.T) {
+	v := languageVerbalizers["en_US"]
+	v.compile()
+	text := "meet on Main St. at 5pm"
+	for _, segment := range v.verbalize(text) {
+		if text[segment.offset:segment.offset+len(segment.source)] != segment.source {
+			t.Errorf("segment %q has source %q at %d", segment.text, segment.source, segment.offset)
+		}
+	}
+	segments := v.verbalize(text)
+	if got := segments[len(segments)-1]; got.text != "five p m" || got.source != "5pm" || !got.expanded {
+		t.Errorf("last segment is %+v", got)
+	}
+}